@@ -621,6 +621,30 @@ func (z *ZoneConfig) LeasePreference(i int) cat.ConstraintSet {
 	return &z.LeasePreferences[i]
 }
 
+// SubzoneCount is part of the cat.Zone interface.
+func (z *ZoneConfig) SubzoneCount() int {
+	return len(z.Subzones)
+}
+
+// Subzone is part of the cat.Zone interface.
+func (z *ZoneConfig) Subzone(i int) (indexID cat.StableID, partition string, zone cat.Zone) {
+	subzone := &z.Subzones[i]
+	return cat.StableID(subzone.IndexID), subzone.PartitionName, &subzone.Config
+}
+
+// ConstraintsString is part of the cat.Zone interface.
+func (z *ZoneConfig) ConstraintsString() (string, error) {
+	return yamlMarshalFlow(ConstraintsList{
+		Constraints: z.Constraints,
+		Inherited:   z.InheritedConstraints,
+	})
+}
+
+// LeasePreferencesString is part of the cat.Zone interface.
+func (z *ZoneConfig) LeasePreferencesString() (string, error) {
+	return yamlMarshalFlow(z.LeasePreferences)
+}
+
 // ConstraintCount is part of the cat.LeasePreference interface.
 func (l *LeasePreference) ConstraintCount() int {
 	return len(l.Constraints)
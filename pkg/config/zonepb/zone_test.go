@@ -17,6 +17,7 @@ import (
 	"testing"
 
 	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/sql/opt/cat"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
@@ -383,6 +384,112 @@ func TestZoneConfigSubzones(t *testing.T) {
 	}
 }
 
+func TestZoneConfigCatSubzones(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	var zone cat.Zone = &ZoneConfig{}
+	require.Equal(t, 0, zone.SubzoneCount())
+
+	indexZone := ZoneConfig{NumReplicas: proto.Int32(5)}
+	partitionZone := ZoneConfig{
+		LeasePreferences: []LeasePreference{
+			{Constraints: []Constraint{{Type: Constraint_REQUIRED, Key: "region", Value: "us-east1"}}},
+		},
+	}
+	zone = &ZoneConfig{
+		NumReplicas: proto.Int32(3),
+		Subzones: []Subzone{
+			{IndexID: 1, PartitionName: "p1", Config: partitionZone},
+			{IndexID: 2, Config: indexZone},
+			{IndexID: 2, PartitionName: "p2", Config: DefaultZoneConfig()},
+		},
+	}
+	require.Equal(t, 3, zone.SubzoneCount())
+
+	expected := []struct {
+		indexID   cat.StableID
+		partition string
+		config    ZoneConfig
+	}{
+		{indexID: 1, partition: "p1", config: partitionZone},
+		{indexID: 2, partition: "", config: indexZone},
+		{indexID: 2, partition: "p2", config: DefaultZoneConfig()},
+	}
+	for i, e := range expected {
+		indexID, partition, subzone := zone.Subzone(i)
+		require.Equal(t, e.indexID, indexID)
+		require.Equal(t, e.partition, partition)
+		require.True(t, e.config.Equal(subzone), "subzone %d: %+v", i, subzone)
+	}
+
+	// The subzone config is not merged with its parent.
+	_, _, subzone := zone.Subzone(0)
+	require.Equal(t, 1, subzone.LeasePreferenceCount())
+	require.Nil(t, subzone.(*ZoneConfig).NumReplicas)
+}
+
+func TestZoneConfigCatConstraintsString(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	east := Constraint{Type: Constraint_REQUIRED, Key: "region", Value: "us-east1"}
+	west := Constraint{Type: Constraint_REQUIRED, Key: "region", Value: "us-west1"}
+	noDC2 := Constraint{Type: Constraint_PROHIBITED, Key: "dc", Value: "dc2"}
+
+	testCases := []struct {
+		zone        ZoneConfig
+		constraints string
+		leasePrefs  string
+	}{
+		{
+			zone:        ZoneConfig{},
+			constraints: "[]",
+			leasePrefs:  "[]",
+		},
+		{
+			zone: ZoneConfig{
+				Constraints:          []ConstraintsConjunction{{Constraints: []Constraint{east}}},
+				InheritedConstraints: true,
+			},
+			constraints: "[]",
+			leasePrefs:  "[]",
+		},
+		{
+			zone: ZoneConfig{
+				Constraints: []ConstraintsConjunction{{Constraints: []Constraint{east, noDC2}}},
+				LeasePreferences: []LeasePreference{
+					{Constraints: []Constraint{east}},
+				},
+			},
+			constraints: "[+region=us-east1, -dc=dc2]",
+			leasePrefs:  "[[+region=us-east1]]",
+		},
+		{
+			zone: ZoneConfig{
+				Constraints: []ConstraintsConjunction{
+					{NumReplicas: 1, Constraints: []Constraint{west}},
+					{NumReplicas: 2, Constraints: []Constraint{east}},
+				},
+				LeasePreferences: []LeasePreference{
+					{Constraints: []Constraint{east}},
+					{Constraints: []Constraint{west}},
+				},
+			},
+			constraints: "{+region=us-east1: 2, +region=us-west1: 1}",
+			leasePrefs:  "[[+region=us-east1], [+region=us-west1]]",
+		},
+	}
+
+	for i, tc := range testCases {
+		var zone cat.Zone = &tc.zone
+		constraints, err := zone.ConstraintsString()
+		require.NoError(t, err)
+		require.Equal(t, tc.constraints, constraints, "test case %d", i)
+		leasePrefs, err := zone.LeasePreferencesString()
+		require.NoError(t, err)
+		require.Equal(t, tc.leasePrefs, leasePrefs, "test case %d", i)
+	}
+}
+
 // TestZoneConfigMarshalYAML makes sure that ZoneConfig is correctly marshaled
 // to YAML and back.
 func TestZoneConfigMarshalYAML(t *testing.T) {
@@ -11,6 +11,7 @@
 package zonepb
 
 import (
+	"bytes"
 	"fmt"
 	"runtime/debug"
 	"sort"
@@ -296,3 +297,18 @@ func (c *ZoneConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	*c = zoneConfigFromMarshalable(aux, *c)
 	return nil
 }
+
+// yamlMarshalFlow marshals v to YAML using the flow style, as in
+// [+region=us-east1] or {+region=us-east1: 1}, and trims the trailing newline.
+func yamlMarshalFlow(v interface{}) (string, error) {
+	var buf bytes.Buffer
+	e := yaml.NewEncoder(&buf)
+	e.UseStyle(yaml.FlowStyle)
+	if err := e.Encode(v); err != nil {
+		return "", err
+	}
+	if err := e.Close(); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
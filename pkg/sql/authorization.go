@@ -538,6 +538,15 @@ func (p *planner) canCreateOnSchema(
 
 func (p *planner) canResolveDescUnderSchema(
 	ctx context.Context, schemaID descpb.ID, desc catalog.Descriptor,
+) error {
+	return p.canResolveDescUnderSchemaForUser(ctx, schemaID, desc, p.User())
+}
+
+// canResolveDescUnderSchemaForUser is like canResolveDescUnderSchema, but
+// checks whether the given user, rather than the session user, can access the
+// target schema.
+func (p *planner) canResolveDescUnderSchemaForUser(
+	ctx context.Context, schemaID descpb.ID, desc catalog.Descriptor, user security.SQLUsername,
 ) error {
 	// We can't always resolve temporary schemas by ID (for example in the temporary
 	// object cleaner which accesses temporary schemas not in the current session).
@@ -555,7 +564,7 @@ func (p *planner) canResolveDescUnderSchema(
 		// Anyone can resolve under temporary, public or virtual schemas.
 		return nil
 	case catalog.SchemaUserDefined:
-		return p.CheckPrivilegeForUser(ctx, resolvedSchema.Desc, privilege.USAGE, p.User())
+		return p.CheckPrivilegeForUser(ctx, resolvedSchema.Desc, privilege.USAGE, user)
 	default:
 		panic(errors.AssertionFailedf("unknown schema kind %d", resolvedSchema.Kind))
 	}
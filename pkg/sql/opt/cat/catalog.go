@@ -117,6 +117,15 @@ type Catalog interface {
 		ctx context.Context, flags Flags, id StableID,
 	) (_ DataSource, isAdding bool, _ error)
 
+	// ResolveIndex locates the table with the given name, and then the index
+	// with the given name on that table. If the index name is empty, the
+	// table's primary index is returned. Only public indexes are considered.
+	//
+	// If no such table or index exists, then ResolveIndex returns an error.
+	ResolveIndex(
+		ctx context.Context, tableName *DataSourceName, indexName tree.Name,
+	) (Table, Index, error)
+
 	// ResolveTypeByOID is used to look up a user defined type by ID.
 	ResolveTypeByOID(ctx context.Context, oid oid.Oid) (*types.T, error)
 
@@ -11,9 +11,11 @@
 package cat
 
 import (
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/colinfo"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/errors"
+	"github.com/lib/pq/oid"
 )
 
 // Column stores information about table columns, exposing only the information
@@ -29,9 +31,11 @@ type Column struct {
 	name                        tree.Name
 	kind                        ColumnKind
 	datumType                   *types.T
+	typeOID                     oid.Oid
 	nullable                    bool
 	hidden                      bool
 	defaultExpr                 string
+	defaultSequenceID           StableID
 	computedExpr                string
 	invertedSourceColumnOrdinal int
 }
@@ -84,6 +88,32 @@ func (c *Column) DatumType() *types.T {
 	return c.datumType
 }
 
+// TypeModifier returns the Postgres type modifier (typmod) of the column's type,
+// as it would be reported in pg_attribute.atttypmod. It encodes the width of
+// string and bit types and the precision and scale of decimal types, and is -1
+// if the type has no modifier.
+func (c *Column) TypeModifier() int32 {
+	return c.datumType.TypeModifier()
+}
+
+// TypeOID returns the Postgres OID of the column's type. For user-defined
+// types this is the stable OID derived from the type's descriptor ID. It is
+// equivalent to DatumType().Oid(), but is computed once when the column is
+// initialized.
+func (c *Column) TypeOID() oid.Oid {
+	return c.typeOID
+}
+
+// HasCompositeEncoding returns true if the column's type can have a composite
+// key encoding, as collated strings, floats and decimals (and arrays of them)
+// do. When such a column is part of an index key, the key doesn't necessarily
+// contain enough information to recover the column's value, so the value part
+// of the index entry may need to be decoded as well. See
+// colinfo.HasCompositeKeyEncoding.
+func (c *Column) HasCompositeEncoding() bool {
+	return colinfo.HasCompositeKeyEncoding(c.datumType)
+}
+
 // IsNullable returns true if the column is nullable.
 func (c *Column) IsNullable() bool {
 	return c.nullable
@@ -95,6 +125,15 @@ func (c *Column) IsHidden() bool {
 	return c.hidden
 }
 
+// IsInaccessible returns true if the column can't be referenced by name in
+// queries, even explicitly. This is distinct from IsHidden: a hidden column like
+// rowid is excluded from star expansion but can still be selected by name.
+// Currently, the only inaccessible columns are the virtual columns that refer
+// to the keys of inverted indexes.
+func (c *Column) IsInaccessible() bool {
+	return c.kind == VirtualInverted
+}
+
 // HasDefault returns true if the column has a default value. DefaultExprStr
 // will be set to the SQL expression string in that case.
 func (c *Column) HasDefault() bool {
@@ -109,6 +148,22 @@ func (c *Column) DefaultExprStr() string {
 	return c.defaultExpr
 }
 
+// DefaultSequenceID returns the ID of the sequence referenced by the column's
+// default expression, as in the default of a SERIAL column:
+//
+//   nextval('t_a_seq':::STRING)
+//
+// ok is false if the column has no default, or if its default does not
+// reference exactly one sequence. The default expression refers to the
+// sequence by name; the ID is provided by the catalog when the column is
+// initialized, so that callers don't need to parse and resolve the expression.
+func (c *Column) DefaultSequenceID() (_ StableID, ok bool) {
+	if c.defaultExpr == "" || c.defaultSequenceID == 0 {
+		return 0, false
+	}
+	return c.defaultSequenceID, true
+}
+
 // IsComputed returns true if the column is a computed value. ComputedExprStr
 // will be set to the SQL expression string in that case.
 func (c *Column) IsComputed() bool {
@@ -183,6 +238,7 @@ func (c *Column) InitNonVirtual(
 	nullable bool,
 	hidden bool,
 	defaultExpr *string,
+	defaultSequenceID StableID,
 	computedExpr *string,
 ) {
 	if kind.IsVirtual() {
@@ -193,6 +249,7 @@ func (c *Column) InitNonVirtual(
 	c.name = name
 	c.kind = kind
 	c.datumType = datumType
+	c.typeOID = datumType.Oid()
 	c.nullable = nullable
 	c.hidden = hidden
 	if defaultExpr != nil {
@@ -200,6 +257,7 @@ func (c *Column) InitNonVirtual(
 	} else {
 		c.defaultExpr = ""
 	}
+	c.defaultSequenceID = defaultSequenceID
 	if computedExpr != nil {
 		c.computedExpr = *computedExpr
 	} else {
@@ -218,9 +276,11 @@ func (c *Column) InitVirtualInverted(
 	c.name = name
 	c.kind = VirtualInverted
 	c.datumType = datumType
+	c.typeOID = datumType.Oid()
 	c.nullable = nullable
 	c.hidden = true
 	c.defaultExpr = ""
+	c.defaultSequenceID = 0
 	c.computedExpr = ""
 	c.invertedSourceColumnOrdinal = invertedSourceColumnOrdinal
 }
@@ -235,9 +295,11 @@ func (c *Column) InitVirtualComputed(
 	c.name = name
 	c.kind = VirtualComputed
 	c.datumType = datumType
+	c.typeOID = datumType.Oid()
 	c.nullable = nullable
 	c.hidden = true
 	c.defaultExpr = ""
+	c.defaultSequenceID = 0
 	c.computedExpr = computedExpr
 	c.invertedSourceColumnOrdinal = -1
 }
@@ -50,6 +50,10 @@ type Index interface {
 	// Specifically idx = Table().Index(idx.Ordinal).
 	Ordinal() int
 
+	// IsPrimaryIndex returns true if this is the primary index of its table,
+	// i.e. Ordinal() == PrimaryIndex.
+	IsPrimaryIndex() bool
+
 	// IsUnique returns true if this index is declared as UNIQUE in the schema.
 	IsUnique() bool
 
@@ -251,6 +255,19 @@ type IndexColumn struct {
 	Descending bool
 }
 
+// IndexColumnOrder describes the order required on a single table column, as
+// part of an ordering that an index may or may not provide.
+type IndexColumnOrder struct {
+	// Ordinal is the ordinal of the table column.
+	Ordinal int
+
+	// Descending is true if the column must be ordered from greatest to least.
+	Descending bool
+
+	// NullsLast is true if NULL values must be ordered after all other values.
+	NullsLast bool
+}
+
 // IsMutationIndex is a convenience function that returns true if the index at
 // the given ordinal position is a mutation index.
 func IsMutationIndex(table Table, ord IndexOrdinal) bool {
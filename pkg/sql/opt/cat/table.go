@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
 )
 
 // Table is an interface to a database table, exposing only the information
@@ -61,6 +62,20 @@ type Table interface {
 	//
 	Column(i int) *Column
 
+	// ColumnByID returns the column with the given stable ID, which can be any
+	// kind of column other than a virtual column (virtual columns have no stable
+	// ID). ok is false if the table has no such column.
+	ColumnByID(id StableID) (_ *Column, ok bool)
+
+	// VisibleColumnCount returns the number of visible columns in the table.
+	// These are the public (Ordinary) columns that are not hidden; system
+	// columns, mutation columns and virtual columns are never visible.
+	VisibleColumnCount() int
+
+	// VisibleColumn returns the ith visible column, where i < VisibleColumnCount.
+	// Visible columns are returned in the same relative order as by Column.
+	VisibleColumn(i int) *Column
+
 	// IndexCount returns the number of public indexes defined on this table.
 	// Public indexes are not currently being added or dropped from the table.
 	// This method should be used when mutation columns can be ignored (the common
@@ -87,12 +102,25 @@ type Table interface {
 	// virtual tables, the primary index contains a single, synthesized column.
 	Index(i IndexOrdinal) Index
 
+	// AllIndexes returns all public, write-only, and delete-only indexes defined
+	// on this table, in index ordinal order (so the primary index is always
+	// first). The result has DeletableIndexCount elements, and the ith element
+	// is the same as Index(i).
+	AllIndexes() []Index
+
 	// StatisticCount returns the number of statistics available for the table.
 	StatisticCount() int
 
 	// Statistic returns the ith statistic, where i < StatisticCount.
 	Statistic(i int) TableStatistic
 
+	// EstimatedRowCount returns a static estimate of the number of rows in the
+	// table, and true, for tables that have no statistics but whose size can be
+	// estimated by other means (e.g. some virtual tables). It returns false if no
+	// such estimate is available, in which case the table statistics should be
+	// used instead.
+	EstimatedRowCount() (rowCount uint64, ok bool)
+
 	// CheckCount returns the number of check constraints present on the table.
 	CheckCount() int
 
@@ -110,6 +138,11 @@ type Table interface {
 	// i < FamilyCount.
 	Family(i int) Family
 
+	// IsSingleFamily returns true if the table has only one column family, i.e.
+	// FamilyCount() == 1. This is the case for tables without explicitly
+	// specified families, which have a single synthesized primary family.
+	IsSingleFamily() bool
+
 	// OutboundForeignKeyCount returns the number of outbound foreign key
 	// references (where this is the origin table).
 	OutboundForeignKeyCount() int
@@ -143,6 +176,13 @@ type Table interface {
 type CheckConstraint struct {
 	Constraint string
 	Validated  bool
+
+	// Synthesized is true if the constraint was not defined by the user, but
+	// was instead derived by the catalog from the table definition (e.g. the
+	// check that the values of an enum column are valid members of the enum).
+	// Synthesized constraints must be enforced like any other constraint, but
+	// are not part of the table's DDL.
+	Synthesized bool
 }
 
 // TableStatistic is an interface to a table statistic. Each statistic is
@@ -278,3 +318,46 @@ type UniqueConstraint interface {
 	// needs to be enforced on new mutations.
 	Validated() bool
 }
+
+// TableDescription summarizes the user-visible columns, indexes and
+// constraints of a table, as returned by DescribeTable. It allows callers that
+// need the overall shape of a table to retrieve it in a single call.
+type TableDescription struct {
+	Name tree.Name
+
+	// Columns contains the ordinary (non-mutation, non-system, non-virtual)
+	// columns of the table, in ordinal order.
+	Columns []ColumnDescription
+
+	// Indexes contains the public indexes of the table. The first index is the
+	// primary index.
+	Indexes []IndexDescription
+
+	OutboundForeignKeys []ForeignKeyConstraint
+	InboundForeignKeys  []ForeignKeyConstraint
+	Checks              []CheckConstraint
+}
+
+// ColumnDescription describes a column in a TableDescription.
+type ColumnDescription struct {
+	Name     tree.Name
+	Type     *types.T
+	Nullable bool
+	Hidden   bool
+
+	// DefaultExpr is the SQL expression for the column's default value, or the
+	// empty string if it has none.
+	DefaultExpr string
+}
+
+// IndexDescription describes an index in a TableDescription.
+type IndexDescription struct {
+	Name   tree.Name
+	Unique bool
+
+	// KeyColumns contains the columns which make up the index key, including
+	// any implicit primary key columns. StoredColumns contains the remaining
+	// columns stored in the index.
+	KeyColumns    []IndexColumn
+	StoredColumns []IndexColumn
+}
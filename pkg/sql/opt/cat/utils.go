@@ -121,6 +121,52 @@ func ResolveTableIndex(
 	return found, foundTabName, nil
 }
 
+// DescribeTable assembles a TableDescription for the given table using the
+// cat.Table accessors.
+func DescribeTable(tab Table) *TableDescription {
+	desc := &TableDescription{Name: tab.Name()}
+
+	for i, n := 0, tab.ColumnCount(); i < n; i++ {
+		col := tab.Column(i)
+		if col.Kind() != Ordinary {
+			continue
+		}
+		desc.Columns = append(desc.Columns, ColumnDescription{
+			Name:        col.ColName(),
+			Type:        col.DatumType(),
+			Nullable:    col.IsNullable(),
+			Hidden:      col.IsHidden(),
+			DefaultExpr: col.DefaultExprStr(),
+		})
+	}
+
+	desc.Indexes = make([]IndexDescription, tab.IndexCount())
+	for i := range desc.Indexes {
+		idx := tab.Index(i)
+		idxDesc := &desc.Indexes[i]
+		idxDesc.Name = idx.Name()
+		idxDesc.Unique = idx.IsUnique()
+		for j, n := 0, idx.ColumnCount(); j < n; j++ {
+			if j < idx.KeyColumnCount() {
+				idxDesc.KeyColumns = append(idxDesc.KeyColumns, idx.Column(j))
+			} else {
+				idxDesc.StoredColumns = append(idxDesc.StoredColumns, idx.Column(j))
+			}
+		}
+	}
+
+	for i, n := 0, tab.OutboundForeignKeyCount(); i < n; i++ {
+		desc.OutboundForeignKeys = append(desc.OutboundForeignKeys, tab.OutboundForeignKey(i))
+	}
+	for i, n := 0, tab.InboundForeignKeyCount(); i < n; i++ {
+		desc.InboundForeignKeys = append(desc.InboundForeignKeys, tab.InboundForeignKey(i))
+	}
+	for i, n := 0, tab.CheckCount(); i < n; i++ {
+		desc.Checks = append(desc.Checks, tab.Check(i))
+	}
+	return desc
+}
+
 // FormatTable nicely formats a catalog table using a treeprinter for debugging
 // and testing.
 func FormatTable(cat Catalog, tab Table, tp treeprinter.Node) {
@@ -36,6 +36,34 @@ type Zone interface {
 	// LeasePreference returns the ith lease preference in the zone, where
 	// i < LeasePreferenceCount.
 	LeasePreference(i int) ConstraintSet
+
+	// SubzoneCount returns the number of subzones of this zone. Subzones apply to
+	// an entire index or to a single partition of an index.
+	SubzoneCount() int
+
+	// Subzone returns the ith subzone of the zone, where i < SubzoneCount. It
+	// returns the ID of the index the subzone applies to, the name of the
+	// partition (or the empty string if the subzone applies to the entire index)
+	// and the subzone's own configuration. Note that the configuration does not
+	// include any fields inherited from the parent zone.
+	Subzone(i int) (indexID StableID, partition string, zone Zone)
+
+	// ConstraintsString returns the replica constraints of the zone, formatted
+	// the same way as the constraints field of SHOW ZONE CONFIGURATION. For
+	// example:
+	//
+	//   [+region=us-east1, -dc=dc2]
+	//   {+region=us-east1: 2, +region=us-west1: 1}
+	//
+	// If the zone has no constraints, or inherits them, "[]" is returned.
+	ConstraintsString() (string, error)
+
+	// LeasePreferencesString returns the lease preferences of the zone,
+	// formatted the same way as the lease_preferences field of SHOW ZONE
+	// CONFIGURATION. For example:
+	//
+	//   [[+region=us-east1], [+region=us-west1]]
+	LeasePreferencesString() (string, error)
 }
 
 // ConstraintSet is a set of constraints that apply to a range, restricting
@@ -531,6 +531,12 @@ func (sb *statisticsBuilder) makeTableStatistics(tabID opt.TableID) *props.Stati
 		// No statistics.
 		stats.Available = false
 		stats.RowCount = unknownRowCount
+		if rowCount, ok := tab.EstimatedRowCount(); ok {
+			// Some tables (e.g. some virtual tables) can estimate their size
+			// without statistics. Make sure the row count is at least 1, for the
+			// same reason as below.
+			stats.RowCount = math.Max(float64(rowCount), 1)
+		}
 	} else {
 		// Get the RowCount from the most recent statistic. Stats are ordered
 		// with most recent first.
@@ -194,6 +194,7 @@ func TestMetadataTables(t *testing.T) {
 			false, /* nullable */
 			false, /* hidden */
 			nil,   /* defaultExpr */
+			0,     /* defaultSequenceID */
 			nil,   /* computedExpr */
 		)
 		return c
@@ -1436,6 +1436,7 @@ func (ot *OptTester) createTableAs(name tree.TableName, rel memo.RelExpr) (*test
 			!relProps.NotNullCols.Contains(col),
 			false, /* hidden */
 			nil,   /* defaultExpr */
+			0,     /* defaultSequenceID */
 			nil,   /* computedExpr */
 		)
 
@@ -113,6 +113,7 @@ func (tc *Catalog) CreateTable(stmt *tree.CreateTable) *Table {
 			false,              /* nullable */
 			true,               /* hidden */
 			&uniqueRowIDString, /* defaultExpr */
+			0,                  /* defaultSequenceID */
 			nil,                /* computedExpr */
 		)
 		tab.Columns = append(tab.Columns, rowid)
@@ -140,6 +141,7 @@ func (tc *Catalog) CreateTable(stmt *tree.CreateTable) *Table {
 		true, /* nullable */
 		true, /* hidden */
 		nil,  /* defaultExpr */
+		0,    /* defaultSequenceID */
 		nil,  /* computedExpr */
 	)
 	tab.Columns = append(tab.Columns, mvcc)
@@ -269,6 +271,7 @@ func (tc *Catalog) createVirtualTable(stmt *tree.CreateTable) *Table {
 		false, /* nullable */
 		true,  /* hidden */
 		nil,   /* defaultExpr */
+		0,     /* defaultSequenceID */
 		nil,   /* computedExpr */
 	)
 
@@ -325,6 +328,7 @@ func (tc *Catalog) CreateTableAs(name tree.TableName, columns []cat.Column) *Tab
 		false,              /* nullable */
 		true,               /* hidden */
 		&uniqueRowIDString, /* defaultExpr */
+		0,                  /* defaultSequenceID */
 		nil,                /* computedExpr */
 	)
 
@@ -515,6 +519,7 @@ func (tt *Table) addColumn(def *tree.ColumnTableDef) {
 		nullable,
 		false, /* hidden */
 		defaultExpr,
+		0, /* defaultSequenceID */
 		computedExpr,
 	)
 	tt.Columns = append(tt.Columns, col)
@@ -575,6 +580,7 @@ func (tt *Table) addIndex(def *tree.IndexTableDef, typ indexType) *Index {
 				false, /* nullable */
 				col.IsHidden(),
 				defaultExpr,
+				0, /* defaultSequenceID */
 				computedExpr,
 			)
 		}
@@ -173,6 +173,19 @@ func (tc *Catalog) ResolveDataSourceByID(
 		"relation [%d] does not exist", id)
 }
 
+// ResolveIndex is part of the cat.Catalog interface.
+func (tc *Catalog) ResolveIndex(
+	ctx context.Context, tableName *cat.DataSourceName, indexName tree.Name,
+) (cat.Table, cat.Index, error) {
+	idx, _, err := cat.ResolveTableIndex(
+		ctx, tc, cat.Flags{}, &tree.TableIndexName{Table: *tableName, Index: tree.UnrestrictedName(indexName)},
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	return idx.Table(), idx, nil
+}
+
 // ResolveTypeByOID is part of the cat.Catalog interface.
 func (tc *Catalog) ResolveTypeByOID(context.Context, oid.Oid) (*types.T, error) {
 	return nil, errors.Newf("test catalog cannot handle user defined types")
@@ -643,6 +656,46 @@ func (tt *Table) Column(i int) *cat.Column {
 	return &tt.Columns[i]
 }
 
+// ColumnByID is part of the cat.Table interface.
+func (tt *Table) ColumnByID(id cat.StableID) (_ *cat.Column, ok bool) {
+	for i := range tt.Columns {
+		col := &tt.Columns[i]
+		if !col.Kind().IsVirtual() && col.ColID() == id {
+			return col, true
+		}
+	}
+	return nil, false
+}
+
+// VisibleColumnCount is part of the cat.Table interface.
+func (tt *Table) VisibleColumnCount() int {
+	n := 0
+	for i := range tt.Columns {
+		if isVisibleColumn(&tt.Columns[i]) {
+			n++
+		}
+	}
+	return n
+}
+
+// VisibleColumn is part of the cat.Table interface.
+func (tt *Table) VisibleColumn(i int) *cat.Column {
+	n := 0
+	for j := range tt.Columns {
+		if isVisibleColumn(&tt.Columns[j]) {
+			if n == i {
+				return &tt.Columns[j]
+			}
+			n++
+		}
+	}
+	panic(errors.AssertionFailedf("visible column %d out of range", i))
+}
+
+func isVisibleColumn(col *cat.Column) bool {
+	return col.Kind() == cat.Ordinary && !col.IsHidden()
+}
+
 // IndexCount is part of the cat.Table interface.
 func (tt *Table) IndexCount() int {
 	return len(tt.Indexes) - tt.writeOnlyIdxCount - tt.deleteOnlyIdxCount
@@ -663,6 +716,15 @@ func (tt *Table) Index(i cat.IndexOrdinal) cat.Index {
 	return tt.Indexes[i]
 }
 
+// AllIndexes is part of the cat.Table interface.
+func (tt *Table) AllIndexes() []cat.Index {
+	res := make([]cat.Index, len(tt.Indexes))
+	for i := range tt.Indexes {
+		res[i] = tt.Indexes[i]
+	}
+	return res
+}
+
 // StatisticCount is part of the cat.Table interface.
 func (tt *Table) StatisticCount() int {
 	return len(tt.Stats)
@@ -673,6 +735,11 @@ func (tt *Table) Statistic(i int) cat.TableStatistic {
 	return tt.Stats[i]
 }
 
+// EstimatedRowCount is part of the cat.Table interface.
+func (tt *Table) EstimatedRowCount() (rowCount uint64, ok bool) {
+	return 0, false
+}
+
 // CheckCount is part of the cat.Table interface.
 func (tt *Table) CheckCount() int {
 	return len(tt.Checks)
@@ -688,6 +755,11 @@ func (tt *Table) FamilyCount() int {
 	return len(tt.Families)
 }
 
+// IsSingleFamily is part of the cat.Table interface.
+func (tt *Table) IsSingleFamily() bool {
+	return tt.FamilyCount() == 1
+}
+
 // Family is part of the cat.Table interface.
 func (tt *Table) Family(i int) cat.Family {
 	return tt.Families[i]
@@ -804,6 +876,11 @@ func (ti *Index) Ordinal() int {
 	return ti.ordinal
 }
 
+// IsPrimaryIndex is part of the cat.Index interface.
+func (ti *Index) IsPrimaryIndex() bool {
+	return ti.ordinal == cat.PrimaryIndex
+}
+
 // IsUnique is part of the cat.Index interface.
 func (ti *Index) IsUnique() bool {
 	return ti.Unique
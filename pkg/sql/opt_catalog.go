@@ -12,33 +12,46 @@ package sql
 
 import (
 	"context"
+	"encoding/binary"
+	"hash/fnv"
 	"math"
+	"sort"
+	"strconv"
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/config"
 	"github.com/cockroachdb/cockroach/pkg/config/zonepb"
 	"github.com/cockroachdb/cockroach/pkg/geo/geoindex"
+	"github.com/cockroachdb/cockroach/pkg/jobs"
 	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/kv"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/catalogkv"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/catconstants"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/colinfo"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/dbdesc"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/resolver"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/tabledesc"
 	"github.com/cockroachdb/cockroach/pkg/sql/opt/cat"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
 	"github.com/cockroachdb/cockroach/pkg/sql/roleoption"
 	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemaexpr"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlerrors"
 	"github.com/cockroachdb/cockroach/pkg/sql/stats"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
 	"github.com/cockroachdb/errors"
 	"github.com/lib/pq/oid"
 )
@@ -61,6 +74,14 @@ type optCatalog struct {
 	// something outside of the descriptor has changed (e.g. table stats).
 	dataSources map[*tabledesc.Immutable]cat.DataSource
 
+	// publicSchemas caches the public schema objects returned by ResolveSchema,
+	// keyed by the ID of their database. It is cleared for each query.
+	publicSchemas map[descpb.ID]*optSchema
+
+	// changefeedTables caches the results of HasActiveChangefeed, keyed by table
+	// ID. It is cleared for each query.
+	changefeedTables map[cat.StableID]bool
+
 	// tn is a temporary name used during resolution to avoid heap allocation.
 	tn tree.TableName
 }
@@ -83,6 +104,8 @@ func (oc *optCatalog) reset() {
 	if len(oc.dataSources) > 100 {
 		oc.dataSources = make(map[*tabledesc.Immutable]cat.DataSource)
 	}
+	oc.publicSchemas = nil
+	oc.changefeedTables = nil
 
 	oc.cfg = oc.planner.execCfg.SystemConfig.GetSystemConfig()
 }
@@ -141,6 +164,39 @@ func (os *optSchema) GetDataSourceNames(ctx context.Context) ([]cat.DataSourceNa
 	)
 }
 
+// Owner returns the owner of the schema. User-defined schemas are owned by the
+// user recorded in their descriptor, and the public schema is owned by the
+// admin role. Other schemas (virtual and temporary schemas) have no owner, in
+// which case the empty username is returned.
+func (os *optSchema) Owner() security.SQLUsername {
+	switch os.schema.Kind {
+	case catalog.SchemaUserDefined:
+		return os.schema.Desc.GetPrivileges().Owner()
+	case catalog.SchemaPublic:
+		return security.AdminRoleName()
+	default:
+		return security.SQLUsername{}
+	}
+}
+
+// TemporarySessionID returns the ID of the session that owns the schema and
+// true if it is a temporary schema, or false otherwise. The ID has the same
+// format as the session_id session variable.
+func (os *optSchema) TemporarySessionID() (string, bool) {
+	return temporarySessionIDForSchema(os.schema.Name)
+}
+
+// temporarySessionIDForSchema returns the ID of the session that owns the
+// temporary schema with the given name, or false if it is not the name of a
+// temporary schema.
+func temporarySessionIDForSchema(scName string) (string, bool) {
+	isTemp, sessionID, err := temporarySchemaSessionID(scName)
+	if err != nil || !isTemp {
+		return "", false
+	}
+	return sessionID.String(), true
+}
+
 func (os *optSchema) getDescriptorForPermissionsCheck() catalog.Descriptor {
 	// If the schema is backed by a descriptor, then return it.
 	if os.schema.Kind == catalog.SchemaUserDefined {
@@ -150,6 +206,51 @@ func (os *optSchema) getDescriptorForPermissionsCheck() catalog.Descriptor {
 	return os.database
 }
 
+// ResolveDatabase locates the database with the given name. If no such
+// database exists, then ResolveDatabase returns an error.
+func (oc *optCatalog) ResolveDatabase(ctx context.Context, name string) (cat.Object, error) {
+	desc, err := oc.planner.LogicalSchemaAccessor().GetDatabaseDesc(
+		ctx, oc.planner.Txn(), oc.codec(), name, oc.planner.CommonLookupFlags(true /* required */),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &optDatabase{desc: desc.(*dbdesc.Immutable)}, nil
+}
+
+// GetSchemaNames returns the fully qualified names of all the schemas in the
+// given database: the public schema, user-defined (and temporary) schemas,
+// sorted by name, followed by the virtual schemas.
+func (oc *optCatalog) GetSchemaNames(ctx context.Context, dbName string) ([]cat.SchemaName, error) {
+	dbDesc, err := oc.planner.LogicalSchemaAccessor().GetDatabaseDesc(
+		ctx, oc.planner.Txn(), oc.codec(), dbName, oc.planner.CommonLookupFlags(true /* required */),
+	)
+	if err != nil {
+		return nil, err
+	}
+	schemas, err := oc.planner.Descriptors().GetSchemasForDatabase(ctx, oc.planner.Txn(), dbDesc.GetID())
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(schemas))
+	for _, name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	names = append(names, oc.planner.getVirtualTabler().getSchemaNames()...)
+
+	res := make([]cat.SchemaName, len(names))
+	for i, name := range names {
+		res[i] = cat.SchemaName{
+			CatalogName:     tree.Name(dbName),
+			SchemaName:      tree.Name(name),
+			ExplicitCatalog: true,
+			ExplicitSchema:  true,
+		}
+	}
+	return res, nil
+}
+
 // ResolveSchema is part of the cat.Catalog interface.
 func (oc *optCatalog) ResolveSchema(
 	ctx context.Context, flags cat.Flags, name *cat.SchemaName,
@@ -183,17 +284,69 @@ func (oc *optCatalog) ResolveSchema(
 	}
 
 	prefix := prefixI.(*catalog.ResolvedObjectPrefix)
-	return &optSchema{
+	db := prefix.Database.(*dbdesc.Immutable)
+	resolvedName := oc.tn.ObjectNamePrefix
+	isPublic := prefix.Schema.Kind == catalog.SchemaPublic
+	if isPublic {
+		// Reuse the cached public schema object, as long as it was resolved from
+		// the same version of the database descriptor and with the same name.
+		os, ok := oc.publicSchemas[db.GetID()]
+		if ok && os.database.GetVersion() == db.GetVersion() && os.name == resolvedName {
+			return os, resolvedName, nil
+		}
+	}
+
+	os := &optSchema{
 		planner:  oc.planner,
-		database: prefix.Database.(*dbdesc.Immutable),
+		database: db,
 		schema:   prefix.Schema,
-		name:     oc.tn.ObjectNamePrefix,
-	}, oc.tn.ObjectNamePrefix, nil
+		name:     resolvedName,
+	}
+	if isPublic {
+		if oc.publicSchemas == nil {
+			oc.publicSchemas = make(map[descpb.ID]*optSchema)
+		}
+		oc.publicSchemas[db.GetID()] = os
+	}
+	return os, resolvedName, nil
 }
 
 // ResolveDataSource is part of the cat.Catalog interface.
 func (oc *optCatalog) ResolveDataSource(
 	ctx context.Context, flags cat.Flags, name *cat.DataSourceName,
+) (cat.DataSource, cat.DataSourceName, error) {
+	return oc.resolveDataSourceForUser(ctx, flags, name, oc.planner.User())
+}
+
+// ResolveDataSourceAsOwner is like ResolveDataSource, but performs privilege
+// checks on behalf of the given owner instead of the session user. This is
+// used to resolve data sources referenced by objects with definer rights,
+// which are accessed with the privileges of the object's owner.
+//
+// Since the privilege checks that the optbuilder performs after resolution are
+// always made for the session user, the owner's SELECT privilege on the data
+// source is checked here as well.
+func (oc *optCatalog) ResolveDataSourceAsOwner(
+	ctx context.Context, flags cat.Flags, name *cat.DataSourceName, owner security.SQLUsername,
+) (cat.DataSource, cat.DataSourceName, error) {
+	ds, resName, err := oc.resolveDataSourceForUser(ctx, flags, name, owner)
+	if err != nil {
+		return nil, cat.DataSourceName{}, err
+	}
+	desc, err := getDescFromCatalogObjectForPermissions(ds)
+	if err != nil {
+		return nil, cat.DataSourceName{}, err
+	}
+	if err := oc.planner.CheckPrivilegeForUser(ctx, desc, privilege.SELECT, owner); err != nil {
+		return nil, cat.DataSourceName{}, err
+	}
+	return ds, resName, nil
+}
+
+// resolveDataSourceForUser resolves the given data source name, checking that
+// the given user can access the schema that contains it.
+func (oc *optCatalog) resolveDataSourceForUser(
+	ctx context.Context, flags cat.Flags, name *cat.DataSourceName, user security.SQLUsername,
 ) (cat.DataSource, cat.DataSourceName, error) {
 	if flags.AvoidDescriptorCaches {
 		defer func(prev bool) {
@@ -209,8 +362,10 @@ func (oc *optCatalog) ResolveDataSource(
 		return nil, cat.DataSourceName{}, err
 	}
 
-	// Ensure that the current user can access the target schema.
-	if err := oc.planner.canResolveDescUnderSchema(ctx, desc.GetParentSchemaID(), desc); err != nil {
+	// Ensure that the user can access the target schema.
+	if err := oc.planner.canResolveDescUnderSchemaForUser(
+		ctx, desc.GetParentSchemaID(), desc, user,
+	); err != nil {
 		return nil, cat.DataSourceName{}, err
 	}
 
@@ -221,6 +376,26 @@ func (oc *optCatalog) ResolveDataSource(
 	return ds, oc.tn, nil
 }
 
+// ResolveDataSourcePrefix returns the name of the schema that the given data
+// source name resolves against. Names that are not fully qualified are
+// resolved using the current database and search path, in the same way as
+// ResolveDataSource, but no data source is built for the object. It returns
+// false if no data source with the given name exists in any of the candidate
+// schemas.
+func (oc *optCatalog) ResolveDataSourcePrefix(
+	ctx context.Context, name *cat.DataSourceName,
+) (cat.SchemaName, bool, error) {
+	tn := *name
+	lflags := tree.ObjectLookupFlagsWithRequiredTableKind(tree.ResolveAnyTableKind)
+	lflags.Required = false
+	lflags.AllowWithoutPrimaryKey = true
+	desc, err := resolver.ResolveExistingTableObject(ctx, oc.planner, &tn, lflags)
+	if err != nil || desc == nil {
+		return cat.SchemaName{}, false, err
+	}
+	return tn.ObjectNamePrefix, true, nil
+}
+
 // ResolveDataSourceByID is part of the cat.Catalog interface.
 func (oc *optCatalog) ResolveDataSourceByID(
 	ctx context.Context, flags cat.Flags, dataSourceID cat.StableID,
@@ -247,6 +422,67 @@ func (oc *optCatalog) ResolveDataSourceByID(
 	return ds, false, err
 }
 
+// ResolveAddingDataSourceByID is like ResolveDataSourceByID, except that it
+// also returns a wrapper for a table that is still being added (e.g. by CREATE
+// TABLE AS or a CREATE TABLE with a foreign key), so that its structure can be
+// inspected while it is backfilled. Such tables (and materialized views) are
+// always returned as an optTable with the descpb.DescriptorState_ADD State;
+// callers must check the state before reading from the table. Wrappers for
+// adding tables are not cached and have no statistics.
+func (oc *optCatalog) ResolveAddingDataSourceByID(
+	ctx context.Context, dataSourceID cat.StableID,
+) (cat.DataSource, error) {
+	ds, isAdding, err := oc.ResolveDataSourceByID(ctx, cat.Flags{}, dataSourceID)
+	if !isAdding {
+		return ds, err
+	}
+
+	// Adding descriptors can't be leased, but they can be read as mutable
+	// descriptors (with hydrated types).
+	mut, err := oc.planner.Descriptors().GetMutableTableVersionByID(
+		ctx, descpb.ID(dataSourceID), oc.planner.Txn(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	desc := tabledesc.NewImmutable(*mut.TableDesc())
+	if !desc.IsTable() && !desc.MaterializedView() {
+		return nil, errors.AssertionFailedf("unexpected adding descriptor: %+v", desc)
+	}
+	zoneConfig, err := oc.getZoneConfig(desc)
+	if err != nil {
+		return nil, err
+	}
+	return newOptTable(desc, oc.codec(), &oc.planner.ExecCfg().Settings.SV, nil /* stats */, zoneConfig)
+}
+
+// Warm resolves the data sources with the given IDs so that their wrappers,
+// along with the table statistics and zone configs they were built from, are
+// cached for later resolutions. It can be called when a statement is prepared
+// to reduce the latency of its first execution. Stale cache entries are
+// rebuilt as usual when the data source is resolved again.
+func (oc *optCatalog) Warm(ctx context.Context, ids []cat.StableID) error {
+	for _, id := range ids {
+		if _, _, err := oc.ResolveDataSourceByID(ctx, cat.Flags{}, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ResolveIndex is part of the cat.Catalog interface.
+func (oc *optCatalog) ResolveIndex(
+	ctx context.Context, tableName *cat.DataSourceName, indexName tree.Name,
+) (cat.Table, cat.Index, error) {
+	idx, _, err := cat.ResolveTableIndex(
+		ctx, oc, cat.Flags{}, &tree.TableIndexName{Table: *tableName, Index: tree.UnrestrictedName(indexName)},
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	return idx.Table(), idx, nil
+}
+
 // ResolveTypeByOID is part of the cat.Catalog interface.
 func (oc *optCatalog) ResolveTypeByOID(ctx context.Context, oid oid.Oid) (*types.T, error) {
 	return oc.planner.ResolveTypeByOID(ctx, oid)
@@ -259,10 +495,99 @@ func (oc *optCatalog) ResolveType(
 	return oc.planner.ResolveType(ctx, name)
 }
 
+// Describe returns a description of the columns, indexes and constraints of the
+// given data source, which must be a table (see cat.DescribeTable).
+func (oc *optCatalog) Describe(
+	ctx context.Context, ds cat.DataSource,
+) (*cat.TableDescription, error) {
+	tab, ok := ds.(cat.Table)
+	if !ok {
+		return nil, pgerror.Newf(pgcode.WrongObjectType, "%q is not a table", ds.Name())
+	}
+	return cat.DescribeTable(tab), nil
+}
+
+// ResolveTableRecordType returns the implicit record type of the table with the
+// given ID, i.e. the composite type of the table's rows. It is a labeled tuple
+// type with an element for each visible column of the table, in order.
+func (oc *optCatalog) ResolveTableRecordType(
+	ctx context.Context, id cat.StableID,
+) (*types.T, error) {
+	ds, _, err := oc.ResolveDataSourceByID(ctx, cat.Flags{NoTableStats: true}, id)
+	if err != nil {
+		return nil, err
+	}
+	tab, ok := ds.(cat.Table)
+	if !ok {
+		return nil, pgerror.Newf(pgcode.WrongObjectType, "%q is not a table", ds.Name())
+	}
+	contents := make([]*types.T, tab.VisibleColumnCount())
+	labels := make([]string, tab.VisibleColumnCount())
+	for i := range contents {
+		col := tab.VisibleColumn(i)
+		contents[i] = col.DatumType()
+		labels[i] = string(col.ColName())
+	}
+	return types.MakeLabeledTuple(contents, labels), nil
+}
+
+// ResolveTypeOID resolves the given type name and returns its OID. It is the
+// inverse of ResolveTypeByOID, and handles both built-in types (which are
+// resolved through pg_catalog) and user-defined types.
+func (oc *optCatalog) ResolveTypeOID(
+	ctx context.Context, name *tree.UnresolvedObjectName,
+) (oid.Oid, error) {
+	typ, err := oc.ResolveType(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	return typ.Oid(), nil
+}
+
+// RegClass resolves the given data source name and returns its OID, as it
+// would be returned by a 'name'::REGCLASS cast.
+func (oc *optCatalog) RegClass(ctx context.Context, name *cat.DataSourceName) (oid.Oid, error) {
+	ds, _, err := oc.ResolveDataSource(ctx, cat.Flags{NoTableStats: true}, name)
+	if err != nil {
+		return 0, err
+	}
+	return oid.Oid(ds.PostgresDescriptorID()), nil
+}
+
+// ResolveDataSourceByOID is the inverse of RegClass: it resolves the data
+// source with the given OID, as it would be returned by an oid::REGCLASS cast.
+// Unlike type OIDs, which are offset by oidext.CockroachPredefinedOIDMax, the
+// OID of a table, view or sequence is equal to its descriptor ID, so the OID
+// can be used as a StableID directly. InvalidOid (0) never refers to a data
+// source.
+func (oc *optCatalog) ResolveDataSourceByOID(
+	ctx context.Context, flags cat.Flags, o oid.Oid,
+) (cat.DataSource, error) {
+	if o == 0 {
+		return nil, sqlerrors.NewUndefinedRelationError(&tree.TableRef{TableID: int64(o)})
+	}
+	ds, _, err := oc.ResolveDataSourceByID(ctx, flags, cat.StableID(o))
+	return ds, err
+}
+
+// DescriptorModificationTime returns the modification time of the descriptor
+// backing the given catalog object. It changes every time the descriptor is
+// modified, so it can be used to detect that a cached plan that depends on the
+// object is stale.
+func (oc *optCatalog) DescriptorModificationTime(o cat.Object) (hlc.Timestamp, error) {
+	desc, err := getDescFromCatalogObjectForPermissions(o)
+	if err != nil {
+		return hlc.Timestamp{}, err
+	}
+	return desc.GetModificationTime(), nil
+}
+
 func getDescFromCatalogObjectForPermissions(o cat.Object) (catalog.Descriptor, error) {
 	switch t := o.(type) {
 	case *optSchema:
 		return t.getDescriptorForPermissionsCheck(), nil
+	case *optDatabase:
+		return t.desc, nil
 	case *optTable:
 		return t.desc, nil
 	case *optVirtualTable:
@@ -276,6 +601,19 @@ func getDescFromCatalogObjectForPermissions(o cat.Object) (catalog.Descriptor, e
 	}
 }
 
+// UnwrapDescriptor returns the descriptor underlying the given data source,
+// which must have been returned by the optimizer catalog. It is meant for code
+// that needs descriptor details which are not exposed through the cat
+// interfaces. The descriptor is shared with the catalog and must not be
+// modified.
+func UnwrapDescriptor(ds cat.DataSource) (catalog.TableDescriptor, error) {
+	desc, err := getDescForDataSource(ds)
+	if err != nil {
+		return nil, err
+	}
+	return desc, nil
+}
+
 func getDescForDataSource(o cat.DataSource) (*tabledesc.Immutable, error) {
 	switch t := o.(type) {
 	case *optTable:
@@ -291,6 +629,256 @@ func getDescForDataSource(o cat.DataSource) (*tabledesc.Immutable, error) {
 	}
 }
 
+// ReferencingTables returns the tables that reference the table with the given
+// ID through a foreign key, i.e. the origin tables of its inbound foreign keys.
+// Each table is returned once, even if it has several foreign keys referencing
+// the target. A self-referencing table is included in the result.
+func (oc *optCatalog) ReferencingTables(ctx context.Context, id cat.StableID) ([]cat.Table, error) {
+	flags := cat.Flags{NoTableStats: true}
+	ds, _, err := oc.ResolveDataSourceByID(ctx, flags, id)
+	if err != nil {
+		return nil, err
+	}
+	tab, ok := ds.(cat.Table)
+	if !ok {
+		return nil, pgerror.Newf(pgcode.WrongObjectType, "%q is not a table", ds.Name())
+	}
+
+	var res []cat.Table
+	var seen util.FastIntSet
+	for i, n := 0, tab.InboundForeignKeyCount(); i < n; i++ {
+		originID := tab.InboundForeignKey(i).OriginTableID()
+		if seen.Contains(int(originID)) {
+			continue
+		}
+		seen.Add(int(originID))
+		origin, _, err := oc.ResolveDataSourceByID(ctx, flags, originID)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, origin.(cat.Table))
+	}
+	return res, nil
+}
+
+// ValidateForeignKey checks that the two copies of the given foreign key
+// constraint, the outbound one stored on the origin table and the inbound one
+// stored on the referenced table, agree with each other and with fk. It returns
+// an assertion error describing the first difference found, which indicates
+// that the descriptors are corrupted.
+func (oc *optCatalog) ValidateForeignKey(ctx context.Context, fk cat.ForeignKeyConstraint) error {
+	resolveTable := func(id cat.StableID) (cat.Table, error) {
+		ds, _, err := oc.ResolveDataSourceByID(ctx, cat.Flags{NoTableStats: true}, id)
+		if err != nil {
+			return nil, err
+		}
+		tab, ok := ds.(cat.Table)
+		if !ok {
+			return nil, errors.AssertionFailedf(
+				"foreign key %q refers to non-table data source %q", fk.Name(), ds.Name(),
+			)
+		}
+		return tab, nil
+	}
+	origin, err := resolveTable(fk.OriginTableID())
+	if err != nil {
+		return err
+	}
+	referenced, err := resolveTable(fk.ReferencedTableID())
+	if err != nil {
+		return err
+	}
+
+	var outbound, inbound cat.ForeignKeyConstraint
+	for i, n := 0, origin.OutboundForeignKeyCount(); i < n; i++ {
+		if c := origin.OutboundForeignKey(i); c.Name() == fk.Name() && c.ReferencedTableID() == referenced.ID() {
+			outbound = c
+			break
+		}
+	}
+	if outbound == nil {
+		return errors.AssertionFailedf(
+			"foreign key %q is missing from origin table %q", fk.Name(), origin.Name(),
+		)
+	}
+	for i, n := 0, referenced.InboundForeignKeyCount(); i < n; i++ {
+		if c := referenced.InboundForeignKey(i); c.Name() == fk.Name() && c.OriginTableID() == origin.ID() {
+			inbound = c
+			break
+		}
+	}
+	if inbound == nil {
+		return errors.AssertionFailedf(
+			"foreign key %q is missing from referenced table %q", fk.Name(), referenced.Name(),
+		)
+	}
+
+	if err := compareForeignKeys(origin, referenced, outbound, inbound); err != nil {
+		return errors.Wrapf(err, "inbound and outbound foreign key %q differ", fk.Name())
+	}
+	if err := compareForeignKeys(origin, referenced, outbound, fk); err != nil {
+		return errors.Wrapf(err, "foreign key %q differs from the catalog", fk.Name())
+	}
+	return nil
+}
+
+// compareForeignKeys returns an assertion error if the two given foreign key
+// constraints between the origin and referenced tables are not the same.
+func compareForeignKeys(origin, referenced cat.Table, a, b cat.ForeignKeyConstraint) error {
+	if a.ColumnCount() != b.ColumnCount() {
+		return errors.AssertionFailedf("column count %d vs %d", a.ColumnCount(), b.ColumnCount())
+	}
+	for i, n := 0, a.ColumnCount(); i < n; i++ {
+		if aOrd, bOrd := a.OriginColumnOrdinal(origin, i), b.OriginColumnOrdinal(origin, i); aOrd != bOrd {
+			return errors.AssertionFailedf("origin column %d: ordinal %d vs %d", i, aOrd, bOrd)
+		}
+		aOrd, bOrd := a.ReferencedColumnOrdinal(referenced, i), b.ReferencedColumnOrdinal(referenced, i)
+		if aOrd != bOrd {
+			return errors.AssertionFailedf("referenced column %d: ordinal %d vs %d", i, aOrd, bOrd)
+		}
+	}
+	if a.Validated() != b.Validated() {
+		return errors.AssertionFailedf("validated %t vs %t", a.Validated(), b.Validated())
+	}
+	if a.MatchMethod() != b.MatchMethod() {
+		return errors.AssertionFailedf("match method %s vs %s", a.MatchMethod(), b.MatchMethod())
+	}
+	if a.DeleteReferenceAction() != b.DeleteReferenceAction() {
+		return errors.AssertionFailedf(
+			"delete action %s vs %s", a.DeleteReferenceAction(), b.DeleteReferenceAction(),
+		)
+	}
+	if a.UpdateReferenceAction() != b.UpdateReferenceAction() {
+		return errors.AssertionFailedf(
+			"update action %s vs %s", a.UpdateReferenceAction(), b.UpdateReferenceAction(),
+		)
+	}
+	return nil
+}
+
+// SameDatabase returns true if the two data sources belong to the same
+// database. Virtual tables belong to the database they are scoped to (see
+// optVirtualTable.TargetDatabaseID); a virtual table which is not scoped to a
+// database, or which is scoped to a database that does not exist, is not
+// considered to be in the same database as any other data source.
+func (oc *optCatalog) SameDatabase(a, b cat.DataSource) (bool, error) {
+	aID, err := databaseIDForDataSource(a)
+	if err != nil {
+		return false, err
+	}
+	bID, err := databaseIDForDataSource(b)
+	if err != nil {
+		return false, err
+	}
+	return aID != descpb.InvalidID && aID == bID, nil
+}
+
+// databaseIDForDataSource returns the ID of the database the given data source
+// belongs to, or InvalidID if it does not belong to an existing database.
+func databaseIDForDataSource(ds cat.DataSource) (descpb.ID, error) {
+	if vt, ok := ds.(*optVirtualTable); ok {
+		dbID, ok := vt.TargetDatabaseID()
+		if !ok || dbID == math.MaxUint32 {
+			return descpb.InvalidID, nil
+		}
+		return descpb.ID(dbID), nil
+	}
+	desc, err := getDescForDataSource(ds)
+	if err != nil {
+		return descpb.InvalidID, err
+	}
+	return desc.GetParentID(), nil
+}
+
+// Privileges returns the full privilege descriptor (owner and grant list) of the
+// given catalog object. The returned descriptor is a copy, so the caller may
+// modify it without affecting the (possibly shared) catalog descriptor.
+func (oc *optCatalog) Privileges(o cat.Object) (*descpb.PrivilegeDescriptor, error) {
+	desc, err := getDescFromCatalogObjectForPermissions(o)
+	if err != nil {
+		return nil, err
+	}
+	return protoutil.Clone(desc.GetPrivileges()).(*descpb.PrivilegeDescriptor), nil
+}
+
+// HasActiveChangefeed returns true if the given table is watched by a
+// changefeed job that has not finished, i.e. one which is pending, running or
+// paused. Writes to such tables incur the overhead of emitting rangefeed
+// events.
+//
+// There is no cached or leased record of the tables watched by changefeeds, so
+// the jobs are read from system.jobs. The read runs in its own internal
+// transaction rather than the planner's, so that system.jobs does not become
+// part of the read set of the user's transaction and changefeed progress
+// updates cannot force it to retry. The result is cached per table for the
+// duration of the query.
+func (oc *optCatalog) HasActiveChangefeed(ctx context.Context, tab cat.Table) (bool, error) {
+	if active, ok := oc.changefeedTables[tab.ID()]; ok {
+		return active, nil
+	}
+	const stmt = `
+SELECT count(*) > 0 FROM system.jobs
+WHERE status IN ($1, $2, $3)
+AND crdb_internal.pb_to_json(
+  'cockroach.sql.jobs.jobspb.Payload', payload
+)->'changefeed'->'targets' ? $4`
+	row, err := oc.planner.ExecCfg().InternalExecutor.QueryRowEx(
+		ctx,
+		"has-active-changefeed",
+		nil, /* txn */
+		sessiondata.InternalExecutorOverride{User: security.RootUserName()},
+		stmt,
+		jobs.StatusPending,
+		jobs.StatusRunning,
+		jobs.StatusPaused,
+		// The changefeed targets are a map keyed by table ID, so the IDs are the
+		// keys of a JSON object.
+		strconv.FormatUint(uint64(tab.ID()), 10),
+	)
+	if err != nil {
+		return false, err
+	}
+	active := bool(tree.MustBeDBool(row[0]))
+	if oc.changefeedTables == nil {
+		oc.changefeedTables = make(map[cat.StableID]bool)
+	}
+	oc.changefeedTables[tab.ID()] = active
+	return active, nil
+}
+
+// ReserveValues reserves a block of n values of the given sequence, as if
+// nextval had been called n times, and returns the first reserved value. The
+// reserved values are start, start+increment, ..., start+(n-1)*increment.
+// Reserving the values requires the UPDATE privilege on the sequence.
+//
+// The sequence is advanced the same way as by nextval (see
+// planner.incrementSequenceBy). An error is returned if the block would exceed
+// the sequence's bounds; as with nextval, the sequence is still advanced in
+// that case. Sequences with the CYCLE or CACHE options are not supported, so
+// the reserved values never wrap around. Virtual sequences have no stored
+// value, so only a single value can be reserved from them.
+func (oc *optCatalog) ReserveValues(ctx context.Context, seq cat.Sequence, n int64) (int64, error) {
+	if n <= 0 {
+		return 0, errors.AssertionFailedf("cannot reserve %d sequence values", n)
+	}
+	if oc.planner.EvalContext().TxnReadOnly {
+		return 0, readOnlyError("nextval()")
+	}
+	os, ok := seq.(*optSequence)
+	if !ok {
+		return 0, errors.AssertionFailedf("invalid sequence type: %T", seq)
+	}
+	if err := oc.planner.CheckPrivilege(ctx, os.desc, privilege.UPDATE); err != nil {
+		return 0, err
+	}
+	end, err := oc.planner.incrementSequenceBy(ctx, os.desc, n)
+	if err != nil {
+		return 0, err
+	}
+	// incrementSequenceBy made sure that n*increment doesn't overflow.
+	return end - (n-1)*os.desc.SequenceOpts.Increment, nil
+}
+
 // CheckPrivilege is part of the cat.Catalog interface.
 func (oc *optCatalog) CheckPrivilege(ctx context.Context, o cat.Object, priv privilege.Kind) error {
 	desc, err := getDescFromCatalogObjectForPermissions(o)
@@ -372,12 +960,13 @@ func (oc *optCatalog) dataSourceForDesc(
 		return ds, nil
 	}
 
+	fromCache := !oc.planner.avoidCachedDescriptors
 	switch {
 	case desc.IsView():
-		ds = newOptView(desc)
+		ds = newOptView(desc, fromCache)
 
 	case desc.IsSequence():
-		ds = newOptSequence(desc)
+		ds = newOptSequence(desc, fromCache)
 
 	default:
 		return nil, errors.AssertionFailedf("unexpected table descriptor: %+v", desc)
@@ -424,14 +1013,38 @@ func (oc *optCatalog) dataSourceForTable(
 		return ds, nil
 	}
 
-	ds, err := newOptTable(desc, oc.codec(), tableStats, zoneConfig)
+	ds, err := newOptTable(desc, oc.codec(), &oc.planner.ExecCfg().Settings.SV, tableStats, zoneConfig)
 	if err != nil {
 		return nil, err
 	}
+	ds.fromCache = !oc.planner.avoidCachedDescriptors
+	if desc.IsTemporary() {
+		ds.tempSessionID = oc.temporarySessionIDForTable(desc, name)
+	}
 	oc.dataSources[desc] = ds
 	return ds, nil
 }
 
+// temporarySessionIDForTable returns the ID of the session that owns the given
+// temporary table. The session is only recorded in the name of the temporary
+// schema, so it is taken from the name the table was resolved with, or from
+// the current session if the table is in its temporary schema. This avoids
+// reading the schema descriptor while planning. An empty string is returned
+// if the table was looked up by ID in another session's temporary schema.
+func (oc *optCatalog) temporarySessionIDForTable(
+	desc *tabledesc.Immutable, name *cat.DataSourceName,
+) string {
+	if id, ok := temporarySessionIDForSchema(name.Schema()); ok {
+		return id
+	}
+	sd := oc.planner.SessionData()
+	if sd.IsTemporarySchemaID(uint32(desc.GetParentSchemaID())) {
+		id, _ := temporarySessionIDForSchema(sd.SearchPath.GetTemporarySchemaName())
+		return id
+	}
+	return ""
+}
+
 var emptyZoneConfig = &zonepb.ZoneConfig{}
 
 // getZoneConfig returns the ZoneConfig data structure for the given table.
@@ -460,16 +1073,83 @@ func (oc *optCatalog) codec() keys.SQLCodec {
 	return oc.planner.ExecCfg().Codec
 }
 
+// DefaultGCTTL returns the GC TTL of the default zone (RANGE default), which
+// bounds how far in the past an AS OF SYSTEM TIME query can read from tables
+// that don't override it. Like getZoneConfig, it reads the zone from the
+// gossiped SystemConfig, so it might be somewhat stale. If the SystemConfig is
+// not available yet, the TTL of the node's static default zone config is
+// returned instead, as the GC job does.
+func (oc *optCatalog) DefaultGCTTL() (time.Duration, error) {
+	zone := oc.planner.ExecCfg().DefaultZoneConfig
+	if oc.cfg != nil {
+		cfgZone, err := oc.cfg.GetZoneConfigForObject(oc.codec(), keys.RootNamespaceID)
+		if err != nil {
+			return 0, err
+		}
+		if cfgZone != nil {
+			zone = cfgZone
+		}
+	}
+	if zone == nil || zone.GC == nil {
+		return 0, errors.AssertionFailedf("default zone has no GC policy")
+	}
+	return time.Duration(zone.GC.TTLSeconds) * time.Second, nil
+}
+
+// optDatabase is a wrapper around dbdesc.Immutable that implements the
+// cat.Object interface.
+type optDatabase struct {
+	desc *dbdesc.Immutable
+}
+
+var _ cat.Object = &optDatabase{}
+
+// ID is part of the cat.Object interface.
+func (od *optDatabase) ID() cat.StableID {
+	return cat.StableID(od.desc.GetID())
+}
+
+// PostgresDescriptorID is part of the cat.Object interface.
+func (od *optDatabase) PostgresDescriptorID() cat.StableID {
+	return cat.StableID(od.desc.GetID())
+}
+
+// Equals is part of the cat.Object interface.
+func (od *optDatabase) Equals(other cat.Object) bool {
+	otherDatabase, ok := other.(*optDatabase)
+	if !ok {
+		return false
+	}
+	return od.desc.GetID() == otherDatabase.desc.GetID() &&
+		od.desc.GetVersion() == otherDatabase.desc.GetVersion()
+}
+
+// Name returns the name of the database.
+func (od *optDatabase) Name() tree.Name {
+	return tree.Name(od.desc.GetName())
+}
+
 // optView is a wrapper around sqlbase.Immutable that implements
 // the cat.Object, cat.DataSource, and cat.View interfaces.
 type optView struct {
 	desc *tabledesc.Immutable
+
+	// fromCache is true if the descriptor was read through the descriptor
+	// caches. See FromCache.
+	fromCache bool
 }
 
 var _ cat.View = &optView{}
 
-func newOptView(desc *tabledesc.Immutable) *optView {
-	return &optView{desc: desc}
+func newOptView(desc *tabledesc.Immutable, fromCache bool) *optView {
+	return &optView{desc: desc, fromCache: fromCache}
+}
+
+// FromCache returns true if the view's descriptor was read through the
+// descriptor caches, and false if the cache was bypassed because the
+// AvoidDescriptorCaches flag was set.
+func (ov *optView) FromCache() bool {
+	return ov.fromCache
 }
 
 // ID is part of the cat.Object interface.
@@ -520,13 +1200,24 @@ func (ov *optView) ColumnName(i int) tree.Name {
 // implements the cat.Object and cat.DataSource interfaces.
 type optSequence struct {
 	desc *tabledesc.Immutable
+
+	// fromCache is true if the descriptor was read through the descriptor
+	// caches. See FromCache.
+	fromCache bool
 }
 
 var _ cat.DataSource = &optSequence{}
 var _ cat.Sequence = &optSequence{}
 
-func newOptSequence(desc *tabledesc.Immutable) *optSequence {
-	return &optSequence{desc: desc}
+func newOptSequence(desc *tabledesc.Immutable, fromCache bool) *optSequence {
+	return &optSequence{desc: desc, fromCache: fromCache}
+}
+
+// FromCache returns true if the sequence's descriptor was read through the
+// descriptor caches, and false if the cache was bypassed because the
+// AvoidDescriptorCaches flag was set.
+func (os *optSequence) FromCache() bool {
+	return os.fromCache
 }
 
 // ID is part of the cat.Object interface.
@@ -575,6 +1266,18 @@ type optTable struct {
 	// codec is capable of encoding sql table keys.
 	codec keys.SQLCodec
 
+	// sv is used to read the cluster settings that control automatic
+	// statistics collection.
+	sv *settings.Values
+
+	// fromCache is true if the descriptor was read through the descriptor
+	// caches. See FromCache.
+	fromCache bool
+
+	// tempSessionID is the ID of the session that owns the table, if it is a
+	// temporary table. See TemporarySessionID.
+	tempSessionID string
+
 	// rawStats stores the original table statistics slice. Used for a fast-path
 	// check that the statistics haven't changed.
 	rawStats []*stats.TableStatistic
@@ -603,9 +1306,26 @@ type optTable struct {
 	// constraints for user defined types.
 	checkConstraints []cat.CheckConstraint
 
+	// notNullCheckCols is the set of ordinals of columns that validated check
+	// constraints require to be non-NULL. See NotNullConstraintColumns.
+	notNullCheckCols util.FastIntSet
+
 	// colMap is a mapping from unique ColumnID to column ordinal within the
 	// table. This is a common lookup that needs to be fast.
 	colMap map[descpb.ColumnID]int
+
+	// visibleCols contains the ordinals of the visible columns, in order. See
+	// VisibleColumn.
+	visibleCols []int
+
+	// indexedCols is the set of ordinals of columns that are key columns
+	// (including extra primary key columns) or stored columns of at least one
+	// public index. See IsIndexed.
+	indexedCols util.FastIntSet
+
+	// partitioningCols is the set of ordinals of columns that are used to
+	// partition (or subpartition) at least one public index.
+	partitioningCols util.FastIntSet
 }
 
 var _ cat.Table = &optTable{}
@@ -613,12 +1333,14 @@ var _ cat.Table = &optTable{}
 func newOptTable(
 	desc *tabledesc.Immutable,
 	codec keys.SQLCodec,
+	sv *settings.Values,
 	stats []*stats.TableStatistic,
 	tblZone *zonepb.ZoneConfig,
 ) (*optTable, error) {
 	ot := &optTable{
 		desc:     desc,
 		codec:    codec,
+		sv:       sv,
 		rawStats: stats,
 		zone:     tblZone,
 	}
@@ -659,6 +1381,7 @@ func newOptTable(
 			desc.Nullable,
 			desc.Hidden,
 			desc.DefaultExpr,
+			defaultSequenceID(&desc),
 			desc.ComputeExpr,
 		)
 	}
@@ -687,6 +1410,7 @@ func newOptTable(
 				sysCol.Nullable,
 				sysCol.Hidden,
 				sysCol.DefaultExpr,
+				0, /* defaultSequenceID */
 				sysCol.ComputeExpr,
 			)
 		}
@@ -697,6 +1421,7 @@ func newOptTable(
 	for i := range ot.columns {
 		ot.colMap[descpb.ColumnID(ot.columns[i].ColID())] = i
 	}
+	ot.visibleCols = visibleColumnOrdinals(ot.columns)
 
 	// Build the indexes.
 	ot.indexes = make([]optIndex, 1+len(secondaryIndexes))
@@ -750,6 +1475,24 @@ func newOptTable(
 		}
 	}
 
+	// Collect the indexed columns and partitioning columns of all public
+	// indexes. The primary index implicitly stores every column, so only its key
+	// columns are considered to be indexed.
+	for i, n := 0, ot.IndexCount(); i < n; i++ {
+		idx := &ot.indexes[i]
+		numIndexedCols := idx.ColumnCount()
+		if i == cat.PrimaryIndex {
+			numIndexedCols = idx.KeyColumnCount()
+		}
+		for j := 0; j < numIndexedCols; j++ {
+			ot.indexedCols.Add(idx.Column(j).Ordinal())
+		}
+		numPartitionCols := partitioningColumnCount(&idx.desc.Partitioning)
+		for j := 0; j < numPartitionCols; j++ {
+			ot.partitioningCols.Add(idx.Column(j).Ordinal())
+		}
+	}
+
 	for i := range ot.desc.OutboundFKs {
 		fk := &ot.desc.OutboundFKs[i]
 		ot.outboundFKs = append(ot.outboundFKs, optForeignKeyConstraint{
@@ -804,8 +1547,9 @@ func newOptTable(
 					Right:    tree.NewDTuple(colType, tree.MakeAllDEnumsInType(colType)...),
 				}
 				synthesizedChecks = append(synthesizedChecks, cat.CheckConstraint{
-					Constraint: tree.Serialize(expr),
-					Validated:  true,
+					Constraint:  tree.Serialize(expr),
+					Validated:   true,
+					Synthesized: true,
 				})
 			}
 		}
@@ -820,6 +1564,18 @@ func newOptTable(
 		})
 	}
 	ot.checkConstraints = append(ot.checkConstraints, synthesizedChecks...)
+	for i := range ot.checkConstraints {
+		check := &ot.checkConstraints[i]
+		if !check.Validated || check.Synthesized {
+			continue
+		}
+		expr, err := parser.ParseExpr(check.Constraint)
+		if err != nil {
+			// The constraint is unusable for inference; skip it.
+			continue
+		}
+		ot.addNotNullCheckColumns(expr, &ot.notNullCheckCols)
+	}
 
 	// Add stats last, now that other metadata is initialized.
 	if stats != nil {
@@ -874,7 +1630,61 @@ func (ot *optTable) isStale(
 	return false
 }
 
-// Equals is part of the cat.Object interface.
+// Fingerprint returns a hash of the catalog state that Equals compares: the
+// descriptor ID and version, the identity of the statistics (their creation
+// time and columns), the versions of the user-defined types of the columns and
+// the zone of each index. Two wrappers with different fingerprints are never
+// Equal; wrappers with the same fingerprint are very likely (though not
+// guaranteed) to be Equal. Fingerprint must be kept in sync with Equals. An
+// error is returned if the zone of an index can't be encoded.
+func (ot *optTable) Fingerprint() (uint64, error) {
+	h := fnv.New64a()
+	var buf [8]byte
+	writeUint64 := func(v uint64) {
+		binary.BigEndian.PutUint64(buf[:], v)
+		// Writes to a hash.Hash never return an error.
+		_, _ = h.Write(buf[:])
+	}
+
+	writeUint64(uint64(ot.desc.ID))
+	writeUint64(uint64(ot.desc.Version))
+
+	writeUint64(uint64(len(ot.stats)))
+	for i := range ot.stats {
+		stat := &ot.stats[i]
+		writeUint64(uint64(stat.CreatedAt().UnixNano()))
+		writeUint64(uint64(len(stat.columnOrdinals)))
+		for _, c := range stat.columnOrdinals {
+			writeUint64(uint64(c))
+		}
+	}
+
+	cols := ot.desc.DeletableColumns()
+	for _, ord := range ot.desc.GetColumnOrdinalsWithUserDefinedTypes() {
+		writeUint64(uint64(cols[ord].Type.TypeMeta.Version))
+	}
+
+	// Most indexes share the table's zone, so only re-encode the zone if it
+	// differs from the previous index's zone.
+	var prevZone *zonepb.ZoneConfig
+	var zoneBytes []byte
+	for i := range ot.indexes {
+		zone := ot.indexes[i].zone
+		if i == 0 || zone != prevZone {
+			var err error
+			if zoneBytes, err = protoutil.Marshal(zone); err != nil {
+				return 0, errors.Wrapf(err, "failed to marshal zone config of index %q", ot.indexes[i].Name())
+			}
+			prevZone = zone
+		}
+		writeUint64(uint64(len(zoneBytes)))
+		_, _ = h.Write(zoneBytes)
+	}
+	return h.Sum64(), nil
+}
+
+// Equals is part of the cat.Object interface. Fingerprint must hash the same
+// state that is compared here.
 func (ot *optTable) Equals(other cat.Object) bool {
 	otherTable, ok := other.(*optTable)
 	if !ok {
@@ -898,43 +1708,329 @@ func (ot *optTable) Equals(other cat.Object) bool {
 		}
 	}
 
-	// Verify that all of the user defined types in the table are the same.
-	if !ot.desc.UserDefinedTypeColsHaveSameVersion(otherTable.desc) {
+	// Verify that all of the user defined types in the table are the same.
+	if !ot.desc.UserDefinedTypeColsHaveSameVersion(otherTable.desc) {
+		return false
+	}
+
+	// Verify that indexes are in same zones. For performance, skip deep equality
+	// check if it's the same as the previous index (common case).
+	var prevLeftZone, prevRightZone *zonepb.ZoneConfig
+	for i := range ot.indexes {
+		leftZone := ot.indexes[i].zone
+		rightZone := otherTable.indexes[i].zone
+		if leftZone == prevLeftZone && rightZone == prevRightZone {
+			continue
+		}
+		if !leftZone.Equal(rightZone) {
+			return false
+		}
+		prevLeftZone = leftZone
+		prevRightZone = rightZone
+	}
+
+	return true
+}
+
+// Name is part of the cat.Table interface.
+func (ot *optTable) Name() tree.Name {
+	return tree.Name(ot.desc.Name)
+}
+
+// IsVirtualTable is part of the cat.Table interface.
+func (ot *optTable) IsVirtualTable() bool {
+	return false
+}
+
+// IsMaterializedView implements the cat.Table interface.
+func (ot *optTable) IsMaterializedView() bool {
+	return ot.desc.MaterializedView()
+}
+
+// FormatVersion returns the format version of the table's descriptor, which
+// can be used to gate planning features that depend on the descriptor layout.
+func (ot *optTable) FormatVersion() descpb.FormatVersion {
+	return ot.desc.GetFormatVersion()
+}
+
+// NextColumnID returns the ID that will be assigned to the next column added
+// to the table. IDs are never reused, so it is greater than the ID of every
+// column the table has ever had, including dropped columns.
+func (ot *optTable) NextColumnID() descpb.ColumnID {
+	return ot.desc.NextColumnID
+}
+
+// NextIndexID returns the ID that will be assigned to the next index added to
+// the table. Like column IDs, index IDs are never reused.
+func (ot *optTable) NextIndexID() descpb.IndexID {
+	return ot.desc.NextIndexID
+}
+
+// State returns the state of the table's descriptor. Tables which are not
+// PUBLIC (e.g. OFFLINE tables which are being restored or imported) should not
+// be read from.
+func (ot *optTable) State() descpb.DescriptorState {
+	return ot.desc.State
+}
+
+// ActiveMutationJobs returns the IDs of the schema change jobs that are
+// executing the table's in-progress mutations, in the order they appear in the
+// descriptor. It is empty if the table has no schema change in progress.
+func (ot *optTable) ActiveMutationJobs() []int64 {
+	if len(ot.desc.MutationJobs) == 0 {
+		return nil
+	}
+	jobIDs := make([]int64, 0, len(ot.desc.MutationJobs))
+	for i := range ot.desc.MutationJobs {
+		jobIDs = append(jobIDs, ot.desc.MutationJobs[i].JobID)
+	}
+	return jobIDs
+}
+
+// PrimaryKeySwapInProgress returns true if the table's primary key is being
+// changed, e.g. by ALTER PRIMARY KEY. Until the change completes, the current
+// primary index remains the table's primary index and is the one to use for
+// reads.
+func (ot *optTable) PrimaryKeySwapInProgress() bool {
+	return ot.primaryKeySwap() != nil
+}
+
+// PendingPrimaryIndex returns the index that will become the table's primary
+// index once an in-progress primary key change completes. The index is a
+// mutation index, so it must not be used for reads. ok is false if no primary
+// key change is in progress.
+func (ot *optTable) PendingPrimaryIndex() (_ cat.Index, ok bool) {
+	swap := ot.primaryKeySwap()
+	if swap == nil {
+		return nil, false
+	}
+	for i := range ot.indexes {
+		if ot.indexes[i].desc.ID == swap.NewPrimaryIndexId {
+			return &ot.indexes[i], true
+		}
+	}
+	return nil, false
+}
+
+// primaryKeySwap returns the table's pending primary key swap mutation, or nil
+// if there is none.
+func (ot *optTable) primaryKeySwap() *descpb.PrimaryKeySwap {
+	for i := range ot.desc.Mutations {
+		if swap := ot.desc.Mutations[i].GetPrimaryKeySwap(); swap != nil {
+			return swap
+		}
+	}
+	return nil
+}
+
+// FromCache returns true if the table's descriptor was read through the
+// descriptor caches, and false if the cache was bypassed because the
+// AvoidDescriptorCaches flag was set. This is useful when diagnosing issues
+// caused by stale descriptors.
+func (ot *optTable) FromCache() bool {
+	return ot.fromCache
+}
+
+// UsedUserDefinedTypes returns the OIDs of the user-defined types referenced by
+// the table's columns, in the order they are first referenced. The element type
+// of an array of a user-defined type is included along with the array type, as
+// are the types of tuple fields.
+func (ot *optTable) UsedUserDefinedTypes() []oid.Oid {
+	var res []oid.Oid
+	for i := range ot.columns {
+		res = appendUserDefinedTypes(res, ot.columns[i].DatumType())
+	}
+	return res
+}
+
+// appendUserDefinedTypes appends the OIDs of typ and of any types it contains
+// which are user-defined and not already in res.
+func appendUserDefinedTypes(res []oid.Oid, typ *types.T) []oid.Oid {
+	if typ.UserDefined() {
+		found := false
+		for _, o := range res {
+			if o == typ.Oid() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			res = append(res, typ.Oid())
+		}
+	}
+	switch typ.Family() {
+	case types.ArrayFamily:
+		res = appendUserDefinedTypes(res, typ.ArrayContents())
+	case types.TupleFamily:
+		for _, t := range typ.TupleContents() {
+			res = appendUserDefinedTypes(res, t)
+		}
+	}
+	return res
+}
+
+// TemporarySessionID returns the ID of the session that owns the table and true
+// if it is a temporary table, or false if it is a permanent table. The ID has
+// the same format as the session_id session variable. It also returns false
+// for a table in another session's temporary schema that was resolved by ID.
+func (ot *optTable) TemporarySessionID() (string, bool) {
+	if ot.tempSessionID == "" {
+		return "", false
+	}
+	return ot.tempSessionID, true
+}
+
+// AuditMode returns the audit mode of the table, which determines whether
+// accesses to the table are recorded in the audit log.
+func (ot *optTable) AuditMode() descpb.TableDescriptor_AuditMode {
+	return ot.desc.GetAuditMode()
+}
+
+// IsPrimaryKeySharded returns true if the table's primary index is
+// hash-sharded.
+func (ot *optTable) IsPrimaryKeySharded() bool {
+	return ot.desc.PrimaryIndex.IsSharded()
+}
+
+// PrimaryKeyShardBuckets returns the number of shard buckets of the table's
+// primary index, or 0 if the primary index is not hash-sharded.
+func (ot *optTable) PrimaryKeyShardBuckets() int {
+	if !ot.IsPrimaryKeySharded() {
+		return 0
+	}
+	return int(ot.desc.PrimaryIndex.Sharded.ShardBuckets)
+}
+
+// IsRowIDColumn returns true if the column with the given ordinal is the
+// hidden rowid column that is synthesized as the primary key of a table that
+// was created without one. Such a column is hidden, defaults to unique_rowid()
+// and is the only column of the primary index. Note that the column is
+// usually, but not necessarily, named rowid; if that name is taken, a suffix
+// is added.
+func (ot *optTable) IsRowIDColumn(colOrd int) bool {
+	pkColIDs := ot.desc.PrimaryIndex.ColumnIDs
+	if len(pkColIDs) != 1 {
 		return false
 	}
-
-	// Verify that indexes are in same zones. For performance, skip deep equality
-	// check if it's the same as the previous index (common case).
-	var prevLeftZone, prevRightZone *zonepb.ZoneConfig
+	col := ot.Column(colOrd)
+	return col.Kind() == cat.Ordinary &&
+		col.IsHidden() &&
+		descpb.ColumnID(col.ColID()) == pkColIDs[0] &&
+		col.DefaultExprStr() == "unique_rowid()"
+}
+
+// IsIndexed returns true if the column with the given ordinal is a key column
+// of at least one public index, or is stored in a public secondary index.
+// Columns that are only stored in the primary index are not indexed.
+func (ot *optTable) IsIndexed(colOrd int) bool {
+	return ot.indexedCols.Contains(colOrd)
+}
+
+// IndexesReferencingColumn returns the indexes (including mutation indexes)
+// that reference the column with the given ordinal, which are the indexes that
+// are affected if the column is dropped. An index references a column if the
+// column is one of its key columns (including implicit primary key columns),
+// the source of its inverted key, one of its stored columns, or if it is used in
+// its partial index predicate. Note that the primary index stores every column
+// of the table. An error is returned if a partial index predicate can't be
+// parsed.
+func (ot *optTable) IndexesReferencingColumn(colOrd int) ([]cat.Index, error) {
+	var res []cat.Index
 	for i := range ot.indexes {
-		leftZone := ot.indexes[i].zone
-		rightZone := otherTable.indexes[i].zone
-		if leftZone == prevLeftZone && rightZone == prevRightZone {
-			continue
+		ok, err := ot.indexes[i].referencesColumn(colOrd)
+		if err != nil {
+			return nil, err
 		}
-		if !leftZone.Equal(rightZone) {
-			return false
+		if ok {
+			res = append(res, &ot.indexes[i])
 		}
-		prevLeftZone = leftZone
-		prevRightZone = rightZone
 	}
+	return res, nil
+}
 
-	return true
+// referencesColumn returns true if the index references the column with the
+// given ordinal. See optTable.IndexesReferencingColumn.
+func (oi *optIndex) referencesColumn(colOrd int) (bool, error) {
+	for i, n := 0, oi.ColumnCount(); i < n; i++ {
+		col := oi.Column(i)
+		if col.Ordinal() == colOrd {
+			return true, nil
+		}
+		if col.Kind() == cat.VirtualInverted && col.InvertedSourceColumnOrdinal() == colOrd {
+			return true, nil
+		}
+	}
+	if oi.desc.Predicate == "" {
+		return false, nil
+	}
+	col := oi.tab.Column(colOrd)
+	if col.Kind().IsVirtual() {
+		return false, nil
+	}
+	expr, err := parser.ParseExpr(oi.desc.Predicate)
+	if err != nil {
+		return false, errors.Wrapf(err, "parsing predicate of index %q", oi.desc.Name)
+	}
+	colIDs, err := schemaexpr.ExtractColumnIDs(oi.tab.desc, expr)
+	if err != nil {
+		return false, errors.Wrapf(err, "parsing predicate of index %q", oi.desc.Name)
+	}
+	return colIDs.Contains(descpb.ColumnID(col.ColID())), nil
+}
+
+// BestCoveringIndex returns the public index that covers all of the given
+// table column ordinals (see optIndex.CoveredColumns) with the fewest columns,
+// which is usually the cheapest index to scan for a projection of those
+// columns. Ties are broken by optIndex.EstimatedSize, and then in favor of the
+// lower ordinal, so the primary index wins if there is nothing better.
+// Inverted and partial indexes are never returned, since they don't contain an
+// entry for every row of the table. ok is false if no index covers the
+// columns.
+func (ot *optTable) BestCoveringIndex(cols util.FastIntSet) (_ cat.Index, ok bool) {
+	var best *optIndex
+	for i, n := 0, ot.IndexCount(); i < n; i++ {
+		idx := &ot.indexes[i]
+		if idx.IsInverted() || idx.desc.IsPartial() || !cols.SubsetOf(idx.CoveredColumns()) {
+			continue
+		}
+		if best == nil || idx.isCheaperToScan(best) {
+			best = idx
+		}
+	}
+	if best == nil {
+		return nil, false
+	}
+	return best, true
 }
 
-// Name is part of the cat.Table interface.
-func (ot *optTable) Name() tree.Name {
-	return tree.Name(ot.desc.Name)
+// isCheaperToScan returns true if the index has fewer columns than the other
+// index, or the same number of columns and a smaller estimated size. See
+// optTable.BestCoveringIndex.
+func (oi *optIndex) isCheaperToScan(other *optIndex) bool {
+	if n, otherN := oi.CoveredColumns().Len(), other.CoveredColumns().Len(); n != otherN {
+		return n < otherN
+	}
+	size, ok := oi.EstimatedSize()
+	otherSize, otherOK := other.EstimatedSize()
+	return ok && otherOK && size < otherSize
 }
 
-// IsVirtualTable is part of the cat.Table interface.
-func (ot *optTable) IsVirtualTable() bool {
-	return false
+// IsPartitioningColumn returns true if the column with the given ordinal is
+// used to partition or subpartition at least one public index.
+func (ot *optTable) IsPartitioningColumn(colOrd int) bool {
+	return ot.partitioningCols.Contains(colOrd)
 }
 
-// IsMaterializedView implements the cat.Table interface.
-func (ot *optTable) IsMaterializedView() bool {
-	return ot.desc.MaterializedView()
+// partitioningColumnCount returns the number of leading index columns used by
+// the given partitioning, including the columns of any subpartitionings.
+func partitioningColumnCount(p *descpb.PartitioningDescriptor) int {
+	maxSub := 0
+	for i := range p.List {
+		if n := partitioningColumnCount(&p.List[i].Subpartitioning); n > maxSub {
+			maxSub = n
+		}
+	}
+	return int(p.NumColumns) + maxSub
 }
 
 // ColumnCount is part of the cat.Table interface.
@@ -947,6 +2043,25 @@ func (ot *optTable) Column(i int) *cat.Column {
 	return &ot.columns[i]
 }
 
+// VisibleColumnCount is part of the cat.Table interface.
+func (ot *optTable) VisibleColumnCount() int {
+	return len(ot.visibleCols)
+}
+
+// VisibleColumn is part of the cat.Table interface.
+func (ot *optTable) VisibleColumn(i int) *cat.Column {
+	return &ot.columns[ot.visibleCols[i]]
+}
+
+// ColumnByID is part of the cat.Table interface.
+func (ot *optTable) ColumnByID(id cat.StableID) (_ *cat.Column, ok bool) {
+	ord, ok := ot.colMap[descpb.ColumnID(id)]
+	if !ok {
+		return nil, false
+	}
+	return &ot.columns[ord], true
+}
+
 // getColDesc is part of optCatalogTableInterface.
 func (ot *optTable) getColDesc(i int) *descpb.ColumnDescriptor {
 	if i < len(ot.desc.DeletableColumns()) {
@@ -968,6 +2083,13 @@ func (ot *optTable) IndexCount() int {
 	return 1 + len(ot.desc.Indexes)
 }
 
+// HasSecondaryIndexes returns true if the table has at least one public
+// secondary index, i.e. if IndexCount() > 1. Secondary indexes that are still
+// being added or dropped are not counted.
+func (ot *optTable) HasSecondaryIndexes() bool {
+	return len(ot.desc.Indexes) > 0
+}
+
 // WritableIndexCount is part of the cat.Table interface.
 func (ot *optTable) WritableIndexCount() int {
 	// Primary index is always present, so count is always >= 1.
@@ -985,6 +2107,114 @@ func (ot *optTable) Index(i cat.IndexOrdinal) cat.Index {
 	return &ot.indexes[i]
 }
 
+// PrimaryStoredColumns returns the columns of the primary index which are not
+// part of the primary key, in table ordinal order.
+func (ot *optTable) PrimaryStoredColumns() []*cat.Column {
+	pk := &ot.indexes[cat.PrimaryIndex]
+	cols := make([]*cat.Column, len(pk.storedCols))
+	for i, id := range pk.storedCols {
+		ord, _ := ot.lookupColumnOrdinal(id)
+		cols[i] = ot.Column(ord)
+	}
+	return cols
+}
+
+// PartitionZone returns the zone config that applies to the named partition of
+// the index with the given ordinal. The partition's subzone inherits any unset
+// fields from the index zone (which in turn inherits from the table zone). It
+// returns false if there is no subzone for the partition, in which case the
+// index zone applies.
+func (ot *optTable) PartitionZone(indexOrd int, partitionName string) (cat.Zone, bool) {
+	idx := &ot.indexes[indexOrd]
+	subzone := ot.zone.GetSubzoneExact(uint32(idx.desc.ID), partitionName)
+	if subzone == nil || partitionName == "" {
+		return nil, false
+	}
+	zone := subzone.Config
+	zone.InheritFromParent(idx.zone)
+	return &zone, true
+}
+
+// EncodePrimaryKey encodes the given primary key values into the key of the
+// row with that primary key, using the table's codec and primary index. The
+// datums must be given in the order of the primary index columns and have
+// their types. The key doesn't include a column family suffix, so it is the
+// prefix shared by the keys of all the row's column families.
+func (ot *optTable) EncodePrimaryKey(datums tree.Datums) (roachpb.Key, error) {
+	pk := &ot.desc.PrimaryIndex
+	if len(datums) != len(pk.ColumnIDs) {
+		return nil, pgerror.Newf(pgcode.InvalidParameterValue,
+			"expected %d primary key values for table %q, got %d",
+			len(pk.ColumnIDs), ot.desc.Name, len(datums))
+	}
+	colMap := make(map[descpb.ColumnID]int, len(pk.ColumnIDs))
+	for i, colID := range pk.ColumnIDs {
+		ord, err := ot.lookupColumnOrdinal(colID)
+		if err != nil {
+			return nil, err
+		}
+		col := ot.Column(ord)
+		if datums[i] == tree.DNull {
+			return nil, pgerror.Newf(pgcode.NotNullViolation,
+				"null value for primary key column %q", col.ColName())
+		}
+		if typ := datums[i].ResolvedType(); !typ.Equivalent(col.DatumType()) {
+			return nil, pgerror.Newf(pgcode.DatatypeMismatch,
+				"value type %s doesn't match type %s of primary key column %q",
+				typ.SQLString(), col.DatumType().SQLString(), col.ColName())
+		}
+		colMap[colID] = i
+	}
+	keyPrefix := rowenc.MakeIndexKeyPrefix(ot.codec, ot.desc, pk.ID)
+	key, _, err := rowenc.EncodeIndexKey(ot.desc, pk, colMap, datums, keyPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// PartitionKeyPrefix returns the key prefix shared by all rows of the named
+// PARTITION BY LIST partition of the index with the given ordinal. The key is
+// encoded from the partition's values in the same way as the prefixes returned
+// by PartitionByListPrefixes, and it is a prefix of the index span. A partition
+// whose values are all DEFAULT has the prefix of the entire index. It returns
+// false if the index has no list partition with the given name, or if the
+// partition has more than one value tuple, in which case its rows don't share a
+// single prefix. An error is returned if the partition's value can't be
+// decoded.
+func (ot *optTable) PartitionKeyPrefix(
+	indexOrd int, partitionName string,
+) (_ roachpb.Key, ok bool, _ error) {
+	idx := &ot.indexes[indexOrd]
+	part := &idx.desc.Partitioning
+	for i := range part.List {
+		if part.List[i].Name != partitionName {
+			continue
+		}
+		if len(part.List[i].Values) != 1 {
+			return nil, false, nil
+		}
+		var a rowenc.DatumAlloc
+		_, key, err := rowenc.DecodePartitionTuple(
+			&a, ot.codec, ot.desc, idx.desc, part, part.List[i].Values[0], nil, /* prefixDatums */
+		)
+		if err != nil {
+			return nil, false, errors.Wrapf(err, "decoding partition %q", partitionName)
+		}
+		return key, true, nil
+	}
+	return nil, false, nil
+}
+
+// AllIndexes is part of the cat.Table interface.
+func (ot *optTable) AllIndexes() []cat.Index {
+	res := make([]cat.Index, len(ot.indexes))
+	for i := range ot.indexes {
+		res[i] = &ot.indexes[i]
+	}
+	return res
+}
+
 // StatisticCount is part of the cat.Table interface.
 func (ot *optTable) StatisticCount() int {
 	return len(ot.stats)
@@ -995,6 +2225,58 @@ func (ot *optTable) Statistic(i int) cat.TableStatistic {
 	return &ot.stats[i]
 }
 
+// StatsAreStale returns true if the table's statistics should be considered
+// stale, given an estimate of the number of rows that have changed since the
+// most recent statistic was collected. This uses the same threshold as the
+// automatic statistics refresher, which is relative to the row count of the
+// most recent statistic. A table without statistics always has stale stats.
+func (ot *optTable) StatsAreStale(rowCountDelta float64) bool {
+	if len(ot.stats) == 0 {
+		return true
+	}
+	// Statistics are ordered from most to least recent.
+	rowCount := float64(ot.stats[0].RowCount())
+	return rowCountDelta >= float64(stats.StaleRowsThreshold(ot.sv, rowCount))
+}
+
+// DistinctCountForColumns returns the distinct count of the statistic that best
+// matches the given list of column ordinals. A statistic on exactly the set of
+// columns in cols is preferred; otherwise the statistic on the longest prefix
+// of cols is used, in which case the distinct count is a lower bound on the
+// distinct count of the full column list. When several statistics match
+// equally well, the most recent one wins. ok is false if no statistic matches.
+func (ot *optTable) DistinctCountForColumns(cols []int) (distinctCount uint64, ok bool) {
+	bestLen := 0
+	for i := range ot.stats {
+		stat := &ot.stats[i]
+		n := stat.ColumnCount()
+		if n <= bestLen || n > len(cols) {
+			continue
+		}
+		var statCols util.FastIntSet
+		for j := 0; j < n; j++ {
+			statCols.Add(stat.ColumnOrdinal(j))
+		}
+		matches := statCols.Len() == n
+		for j := 0; j < n && matches; j++ {
+			matches = statCols.Contains(cols[j])
+		}
+		if matches {
+			distinctCount, bestLen = stat.DistinctCount(), n
+			if n == len(cols) {
+				break
+			}
+		}
+	}
+	return distinctCount, bestLen > 0
+}
+
+// EstimatedRowCount is part of the cat.Table interface.
+func (ot *optTable) EstimatedRowCount() (rowCount uint64, ok bool) {
+	// Tables rely on their statistics.
+	return 0, false
+}
+
 // CheckCount is part of the cat.Table interface.
 func (ot *optTable) CheckCount() int {
 	return len(ot.checkConstraints)
@@ -1005,11 +2287,75 @@ func (ot *optTable) Check(i int) cat.CheckConstraint {
 	return ot.checkConstraints[i]
 }
 
+// HasUserCheckConstraints returns true if the table has at least one check
+// constraint that was declared by the user, as opposed to one synthesized for
+// a user defined type.
+func (ot *optTable) HasUserCheckConstraints() bool {
+	for i := range ot.checkConstraints {
+		if !ot.checkConstraints[i].Synthesized {
+			return true
+		}
+	}
+	return false
+}
+
+// NotNullConstraintColumns returns the set of ordinals of columns that are
+// required to be non-NULL by a validated check constraint. A check constraint
+// passes when it evaluates to NULL, so a constraint like CHECK (x > 0) does not
+// prevent x from being NULL. Only constraints which evaluate to false when a
+// column is NULL, like CHECK (x IS NOT NULL), are taken into account. The
+// caller must not modify the set.
+func (ot *optTable) NotNullConstraintColumns() util.FastIntSet {
+	return ot.notNullCheckCols
+}
+
+// addNotNullCheckColumns adds to cols the ordinals of the columns that the
+// given check constraint expression requires to be non-NULL. Only conjunctions
+// of IS NOT NULL (or IS DISTINCT FROM NULL) predicates on columns are
+// recognized.
+func (ot *optTable) addNotNullCheckColumns(expr tree.Expr, cols *util.FastIntSet) {
+	var colRef tree.Expr
+	switch t := expr.(type) {
+	case *tree.ParenExpr:
+		ot.addNotNullCheckColumns(t.Expr, cols)
+		return
+	case *tree.AndExpr:
+		ot.addNotNullCheckColumns(t.Left, cols)
+		ot.addNotNullCheckColumns(t.Right, cols)
+		return
+	case *tree.IsNotNullExpr:
+		colRef = t.Expr
+	case *tree.ComparisonExpr:
+		if t.Operator != tree.IsDistinctFrom || t.Right != tree.DNull {
+			return
+		}
+		colRef = t.Left
+	default:
+		return
+	}
+	name, ok := tree.StripParens(colRef).(*tree.UnresolvedName)
+	if !ok || name.NumParts != 1 {
+		return
+	}
+	col, err := ot.desc.FindActiveColumnByName(name.Parts[0])
+	if err != nil {
+		return
+	}
+	if ord, err := ot.lookupColumnOrdinal(col.ID); err == nil {
+		cols.Add(ord)
+	}
+}
+
 // FamilyCount is part of the cat.Table interface.
 func (ot *optTable) FamilyCount() int {
 	return 1 + len(ot.families)
 }
 
+// IsSingleFamily is part of the cat.Table interface.
+func (ot *optTable) IsSingleFamily() bool {
+	return ot.FamilyCount() == 1
+}
+
 // Family is part of the cat.Table interface.
 func (ot *optTable) Family(i int) cat.Family {
 	if i == 0 {
@@ -1018,6 +2364,18 @@ func (ot *optTable) Family(i int) cat.Family {
 	return &ot.families[i-1]
 }
 
+// FamilyColumnSet returns the set of ordinals of the columns in the family
+// with the given ordinal. As with Family, ordinal 0 is the primary family.
+func (ot *optTable) FamilyColumnSet(familyOrd int) util.FastIntSet {
+	var cols util.FastIntSet
+	for _, id := range ot.desc.Families[familyOrd].ColumnIDs {
+		if ord, err := ot.lookupColumnOrdinal(id); err == nil {
+			cols.Add(ord)
+		}
+	}
+	return cols
+}
+
 // OutboundForeignKeyCount is part of the cat.Table interface.
 func (ot *optTable) OutboundForeignKeyCount() int {
 	return len(ot.outboundFKs)
@@ -1038,6 +2396,54 @@ func (ot *optTable) InboundForeignKey(i int) cat.ForeignKeyConstraint {
 	return &ot.inboundFKs[i]
 }
 
+// CascadingInboundForeignKeys returns the inbound foreign key constraints that
+// have a delete or update action which modifies the referencing table (CASCADE,
+// SET NULL or SET DEFAULT). Constraints with NO ACTION or RESTRICT for both
+// actions only require checks, so they are not included.
+func (ot *optTable) CascadingInboundForeignKeys() []cat.ForeignKeyConstraint {
+	var res []cat.ForeignKeyConstraint
+	for i := range ot.inboundFKs {
+		fk := &ot.inboundFKs[i]
+		if isCascadingReferenceAction(fk.DeleteReferenceAction()) ||
+			isCascadingReferenceAction(fk.UpdateReferenceAction()) {
+			res = append(res, fk)
+		}
+	}
+	return res
+}
+
+// isCascadingReferenceAction returns true if the given foreign key action
+// modifies the rows of the referencing table.
+func isCascadingReferenceAction(action tree.ReferenceAction) bool {
+	switch action {
+	case tree.Cascade, tree.SetNull, tree.SetDefault:
+		return true
+	}
+	return false
+}
+
+// ForeignKeyColumns returns the set of ordinals of the table's columns that
+// take part in a foreign key constraint, either as origin columns of an
+// outbound constraint or as referenced columns of an inbound constraint. For a
+// self-referencing constraint, both sides are included.
+func (ot *optTable) ForeignKeyColumns() util.FastIntSet {
+	var cols util.FastIntSet
+	addCols := func(ids []descpb.ColumnID) {
+		for _, id := range ids {
+			if ord, err := ot.lookupColumnOrdinal(id); err == nil {
+				cols.Add(ord)
+			}
+		}
+	}
+	for i := range ot.outboundFKs {
+		addCols(ot.outboundFKs[i].originColumns)
+	}
+	for i := range ot.inboundFKs {
+		addCols(ot.inboundFKs[i].referencedColumns)
+	}
+	return cols
+}
+
 // UniqueCount is part of the cat.Table interface.
 func (ot *optTable) UniqueCount() int {
 	// TODO(rytaft): return the number of unique constraints (both with and
@@ -1050,6 +2456,30 @@ func (ot *optTable) Unique(i int) cat.UniqueConstraint {
 	panic(errors.AssertionFailedf("unique constraint [%d] does not exist", i))
 }
 
+// defaultSequenceID returns the ID of the sequence that is referenced by the
+// column's default expression (e.g. the sequence of a SERIAL column), or zero if
+// the default expression references no sequence or more than one. Default
+// expressions refer to sequences by name, but the IDs of the referenced
+// sequences are recorded in the column descriptor when the default is set.
+func defaultSequenceID(col *descpb.ColumnDescriptor) cat.StableID {
+	if col.DefaultExpr == nil || len(col.UsesSequenceIds) != 1 {
+		return 0
+	}
+	return cat.StableID(col.UsesSequenceIds[0])
+}
+
+// visibleColumnOrdinals returns the ordinals of the given columns which are
+// visible, i.e. public and not hidden.
+func visibleColumnOrdinals(cols []cat.Column) []int {
+	var ords []int
+	for i := range cols {
+		if cols[i].Kind() == cat.Ordinary && !cols[i].IsHidden() {
+			ords = append(ords, i)
+		}
+	}
+	return ords
+}
+
 // lookupColumnOrdinal returns the ordinal of the column with the given ID. A
 // cache makes the lookup O(1).
 func (ot *optTable) lookupColumnOrdinal(colID descpb.ColumnID) (int, error) {
@@ -1081,6 +2511,20 @@ type optIndex struct {
 	// ordinal of the virtual column created to refer to the key of this index.
 	// It is -1 if this is not an inverted index.
 	invertedVirtualColOrd int
+
+	// coveredCols is the set of table column ordinals covered by the index. See
+	// CoveredColumns.
+	coveredCols util.FastIntSet
+
+	// keyCols and laxKeyCols are the sets of table column ordinals of the
+	// index's key and lax key columns. See KeyColumnSet and LaxKeyColumnSet.
+	keyCols    util.FastIntSet
+	laxKeyCols util.FastIntSet
+
+	// jsonFetchExpr is the computed expression of the first key column if this
+	// is a forward index on a column that extracts a field or path from a JSON
+	// column. It is nil otherwise.
+	jsonFetchExpr *tree.BinaryExpr
 }
 
 var _ cat.Index = &optIndex{}
@@ -1149,6 +2593,62 @@ func (oi *optIndex) init(
 		oi.numLaxKeyCols = len(desc.ColumnIDs) + len(desc.ExtraColumnIDs)
 		oi.numKeyCols = oi.numLaxKeyCols
 	}
+
+	oi.coveredCols = util.FastIntSet{}
+	oi.keyCols = util.FastIntSet{}
+	oi.laxKeyCols = util.FastIntSet{}
+	for i := 0; i < oi.numCols; i++ {
+		ord := oi.Column(i).Ordinal()
+		oi.coveredCols.Add(ord)
+		if i < oi.numKeyCols {
+			oi.keyCols.Add(ord)
+		}
+		if i < oi.numLaxKeyCols {
+			oi.laxKeyCols.Add(ord)
+		}
+	}
+
+	oi.jsonFetchExpr = nil
+	if desc.Type == descpb.IndexDescriptor_FORWARD && len(desc.ColumnIDs) > 0 {
+		if ord, err := tab.lookupColumnOrdinal(desc.ColumnIDs[0]); err == nil {
+			oi.jsonFetchExpr = jsonFetchExpr(tab.Column(ord))
+		}
+	}
+}
+
+// jsonFetchExpr returns the parsed computed expression of the given column if
+// it extracts a field or path from a JSON value (e.g. data->>'id'), or nil if
+// the column is not computed or its expression is not a JSON fetch.
+func jsonFetchExpr(col *cat.Column) *tree.BinaryExpr {
+	if !col.IsComputed() {
+		return nil
+	}
+	expr, err := parser.ParseExpr(col.ComputedExprStr())
+	if err != nil {
+		return nil
+	}
+	bin, ok := tree.StripParens(expr).(*tree.BinaryExpr)
+	if !ok {
+		return nil
+	}
+	switch bin.Operator {
+	case tree.JSONFetchVal, tree.JSONFetchText, tree.JSONFetchValPath, tree.JSONFetchTextPath:
+		return bin
+	}
+	return nil
+}
+
+// JSONFetchExpr returns the expression that computes the first key column of
+// the index if the index is a forward index on a computed column that extracts
+// a field or path from a JSON column, as in:
+//
+//   CREATE TABLE t (data JSONB, id STRING AS (data->>'id') STORED, INDEX (id))
+//
+// The optimizer can use such an index to constrain scans on the JSON
+// extraction expression. The returned expression is shared and must not be
+// modified. ok is false for all other indexes.
+func (oi *optIndex) JSONFetchExpr() (_ *tree.BinaryExpr, ok bool) {
+	return oi.jsonFetchExpr, oi.jsonFetchExpr != nil
 }
 
 // ID is part of the cat.Index interface.
@@ -1161,6 +2661,22 @@ func (oi *optIndex) Name() tree.Name {
 	return tree.Name(oi.desc.Name)
 }
 
+// IsPrimaryIndex is part of the cat.Index interface.
+func (oi *optIndex) IsPrimaryIndex() bool {
+	return oi.indexOrdinal == cat.PrimaryIndex
+}
+
+// IsReadable returns true if the index can be used to serve reads. Unlike
+// IsValid, which only considers the index itself, it also requires the table
+// to be public: the indexes of a table that is being added or dropped, or is
+// offline (e.g. because it is being imported or restored), can be missing
+// entries even if they are not mutations. Partial index predicates are
+// validated when the index is created, so a public partial index of a public
+// table is always readable.
+func (oi *optIndex) IsReadable() bool {
+	return oi.tab.desc.Public() && oi.IsValid()
+}
+
 // IsUnique is part of the cat.Index interface.
 func (oi *optIndex) IsUnique() bool {
 	return oi.desc.Unique
@@ -1213,6 +2729,8 @@ func (oi *optIndex) Column(i int) cat.IndexColumn {
 	i -= length
 	length = len(oi.desc.ExtraColumnIDs)
 	if i < length {
+		// Extra columns are always encoded in ascending order, regardless of their
+		// direction in the primary index (see rowenc.EncodeSecondaryIndex).
 		ord, _ := oi.tab.lookupColumnOrdinal(oi.desc.ExtraColumnIDs[i])
 		return cat.IndexColumn{Column: oi.tab.Column(ord), Descending: false}
 	}
@@ -1222,6 +2740,33 @@ func (oi *optIndex) Column(i int) cat.IndexColumn {
 	return cat.IndexColumn{Column: oi.tab.Column(ord), Descending: false}
 }
 
+// KeyColumnDescending returns true if the i-th key column of the index is
+// encoded in descending order, where i < KeyColumnCount(). It is equivalent to
+// Column(i).Descending, but avoids looking up the table column.
+//
+// Note that extra (primary key suffix) columns are always encoded in ascending
+// order in secondary indexes, even if they are descending in the primary
+// index.
+func (oi *optIndex) KeyColumnDescending(i int) bool {
+	if i >= oi.numKeyCols {
+		panic(errors.AssertionFailedf("index column %d is not a key column", i))
+	}
+	if i < len(oi.desc.ColumnIDs) {
+		return oi.desc.ColumnDirections[i] == descpb.IndexDescriptor_DESC
+	}
+	return false
+}
+
+// ReversedColumn returns the i-th index column, as returned by Column, but with
+// its direction flipped. This is the ordering produced by a reverse scan of the
+// index. NULLs sort before all other values in ascending order and after them in
+// descending order, so flipping the direction also flips the NULL ordering.
+func (oi *optIndex) ReversedColumn(i int) cat.IndexColumn {
+	col := oi.Column(i)
+	col.Descending = !col.Descending
+	return col
+}
+
 // VirtualInvertedColumn is part of the cat.Index interface.
 func (oi *optIndex) VirtualInvertedColumn() cat.IndexColumn {
 	if !oi.IsInverted() {
@@ -1238,6 +2783,92 @@ func (oi *optIndex) Predicate() (string, bool) {
 	return oi.desc.Predicate, oi.desc.Predicate != ""
 }
 
+// ProvidesOrdering returns true if scanning the index (in the forward direction)
+// provides the given ordering, i.e. the ordering is a prefix of the index's key
+// columns, with matching directions. Indexes order NULL values before all other
+// values on ascending columns and after them on descending columns, so the
+// required NULL ordering must match the column direction. Inverted indexes
+// don't provide any ordering.
+func (oi *optIndex) ProvidesOrdering(ordering []cat.IndexColumnOrder) bool {
+	if len(ordering) == 0 {
+		return true
+	}
+	if oi.IsInverted() || len(ordering) > oi.KeyColumnCount() {
+		return false
+	}
+	for i := range ordering {
+		col := oi.Column(i)
+		if col.Ordinal() != ordering[i].Ordinal ||
+			col.Descending != ordering[i].Descending ||
+			col.Descending != ordering[i].NullsLast {
+			return false
+		}
+	}
+	return true
+}
+
+// varlenColumnSizeEstimate is the number of bytes that EstimatedSize adds to
+// the fixed size of a variable-length column value, such as a string, to account
+// for its data.
+const varlenColumnSizeEstimate = 32
+
+// EstimatedSize returns a rough estimate of the size of the index in bytes. It
+// is the row count of the table's most recent statistic multiplied by the
+// estimated size of a row of the index, which is the sum of the sizes of the
+// index's columns as derived from their types. ok is false if the table has no
+// statistics.
+func (oi *optIndex) EstimatedSize() (bytes uint64, ok bool) {
+	if len(oi.tab.stats) == 0 {
+		return 0, false
+	}
+	var rowSize uint64
+	for i, n := 0, oi.ColumnCount(); i < n; i++ {
+		col := oi.Column(i).Column
+		if col.Kind() == cat.System {
+			// System columns are not stored in the index.
+			continue
+		}
+		size, variable := tree.DatumTypeSize(col.DatumType())
+		rowSize += uint64(size)
+		if variable {
+			rowSize += varlenColumnSizeEstimate
+		}
+	}
+	// Statistics are ordered from most to least recent.
+	return oi.tab.stats[0].RowCount() * rowSize, true
+}
+
+// CoveredColumns returns the set of ordinals of the table columns that are
+// covered by the index, including key columns, extra (primary key) columns and
+// stored columns. For inverted indexes, the set contains the virtual inverted
+// column rather than its source column. The caller must not modify the set.
+func (oi *optIndex) CoveredColumns() util.FastIntSet {
+	return oi.coveredCols
+}
+
+// KeyColumnSet returns the set of table column ordinals of the index's key
+// columns, i.e. the first KeyColumnCount() columns of the index. The caller
+// must not modify the set.
+func (oi *optIndex) KeyColumnSet() util.FastIntSet {
+	return oi.keyCols
+}
+
+// LaxKeyColumnSet returns the set of table column ordinals of the index's lax
+// key columns, i.e. the first LaxKeyColumnCount() columns of the index. The
+// caller must not modify the set.
+func (oi *optIndex) LaxKeyColumnSet() util.FastIntSet {
+	return oi.laxKeyCols
+}
+
+// IsValid returns true if the index is public, and therefore contains an entry
+// for every row in the table (or, for a partial index, for every row that
+// satisfies the predicate). Indexes that are still being backfilled or are
+// being dropped are mutations, and must not be used to read from.
+func (oi *optIndex) IsValid() bool {
+	isMutation, _ := oi.tab.desc.GetIndexMutationCapabilities(oi.desc.ID)
+	return !isMutation
+}
+
 // Zone is part of the cat.Index interface.
 func (oi *optIndex) Zone() cat.Zone {
 	return oi.zone
@@ -1320,6 +2951,41 @@ func (oi *optIndex) GeoConfig() *geoindex.Config {
 	return &oi.desc.GeoConfig
 }
 
+// StorageParams returns the storage parameters of the index, keyed by the
+// names used in the WITH clause of CREATE INDEX (or, for hash-sharded indexes,
+// by bucket_count). Geospatial indexes report their full configuration,
+// including the values that were not explicitly specified. It returns nil if
+// the index has no storage parameters.
+func (oi *optIndex) StorageParams() map[string]string {
+	var params map[string]string
+	add := func(key, val string) {
+		if params == nil {
+			params = make(map[string]string)
+		}
+		params[key] = val
+	}
+	if oi.desc.IsSharded() {
+		add(`bucket_count`, strconv.Itoa(int(oi.desc.Sharded.ShardBuckets)))
+	}
+	var s2Config *geoindex.S2Config
+	if cfg := oi.desc.GeoConfig.S2Geometry; cfg != nil {
+		s2Config = cfg.S2Config
+		add(`geometry_min_x`, strconv.FormatFloat(cfg.MinX, 'f', -1, 64))
+		add(`geometry_max_x`, strconv.FormatFloat(cfg.MaxX, 'f', -1, 64))
+		add(`geometry_min_y`, strconv.FormatFloat(cfg.MinY, 'f', -1, 64))
+		add(`geometry_max_y`, strconv.FormatFloat(cfg.MaxY, 'f', -1, 64))
+	}
+	if cfg := oi.desc.GeoConfig.S2Geography; cfg != nil {
+		s2Config = cfg.S2Config
+	}
+	if s2Config != nil {
+		add(`s2_max_level`, strconv.Itoa(int(s2Config.MaxLevel)))
+		add(`s2_level_mod`, strconv.Itoa(int(s2Config.LevelMod)))
+		add(`s2_max_cells`, strconv.Itoa(int(s2Config.MaxCells)))
+	}
+	return params
+}
+
 // Version is part of the cat.Index interface.
 func (oi *optIndex) Version() descpb.IndexDescriptorVersion {
 	return oi.desc.Version
@@ -1397,6 +3063,11 @@ func (os *optTableStat) Histogram() []cat.HistogramBucket {
 	return os.stat.Histogram
 }
 
+// HasHistogram returns true if the statistic has a non-empty histogram.
+func (os *optTableStat) HasHistogram() bool {
+	return len(os.stat.Histogram) > 0
+}
+
 // optFamily is a wrapper around descpb.ColumnFamilyDescriptor that keeps a
 // reference to the table wrapper.
 type optFamily struct {
@@ -1507,6 +3178,52 @@ func (fk *optForeignKeyConstraint) ReferencedColumnOrdinal(referencedTable cat.T
 	return ord
 }
 
+// OriginColumnName returns the name of the ith column in the origin table
+// that participates in the foreign key. The given table must be the origin
+// table.
+func (fk *optForeignKeyConstraint) OriginColumnName(originTable cat.Table, i int) tree.Name {
+	return originTable.Column(fk.OriginColumnOrdinal(originTable, i)).ColName()
+}
+
+// ReferencedColumnName returns the name of the ith column in the referenced
+// table that participates in the foreign key. The given table must be the
+// referenced table.
+func (fk *optForeignKeyConstraint) ReferencedColumnName(referencedTable cat.Table, i int) tree.Name {
+	return referencedTable.Column(fk.ReferencedColumnOrdinal(referencedTable, i)).ColName()
+}
+
+// ColumnTypesMatch returns true if each origin column of the foreign key has
+// the same type as the referenced column it is paired with. Foreign keys can
+// pair columns with different but compatible types, e.g. INT4 and INT8; in that
+// case false is returned, and values need to be cast when the reference is
+// validated. The given tables must be the origin and referenced tables.
+func (fk *optForeignKeyConstraint) ColumnTypesMatch(
+	originTable, referencedTable cat.Table,
+) (bool, error) {
+	if originTable.ID() != fk.originTable || referencedTable.ID() != fk.referencedTable {
+		return false, errors.AssertionFailedf(
+			"invalid tables %d and %d passed to ColumnTypesMatch (expected %d and %d)",
+			originTable.ID(), referencedTable.ID(), fk.originTable, fk.referencedTable,
+		)
+	}
+	origin, referenced := originTable.(*optTable), referencedTable.(*optTable)
+	for i := range fk.originColumns {
+		originOrd, err := origin.lookupColumnOrdinal(fk.originColumns[i])
+		if err != nil {
+			return false, err
+		}
+		referencedOrd, err := referenced.lookupColumnOrdinal(fk.referencedColumns[i])
+		if err != nil {
+			return false, err
+		}
+		originType := origin.Column(originOrd).DatumType()
+		if !originType.Identical(referenced.Column(referencedOrd).DatumType()) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
 // Validated is part of the cat.ForeignKeyConstraint interface.
 func (fk *optForeignKeyConstraint) Validated() bool {
 	return fk.validity == descpb.ConstraintValidity_Validated
@@ -1563,6 +3280,10 @@ type optVirtualTable struct {
 	// colMap is a mapping from unique ColumnID to column ordinal within the
 	// table. This is a common lookup that needs to be fast.
 	colMap map[descpb.ColumnID]int
+
+	// visibleCols contains the ordinals of the visible columns, in order. See
+	// VisibleColumn.
+	visibleCols []int
 }
 
 var _ cat.Table = &optVirtualTable{}
@@ -1613,6 +3334,7 @@ func newOptVirtualTable(
 		false, /* nullable */
 		true,  /* hidden */
 		nil,   /* defaultExpr */
+		0,     /* defaultSequenceID */
 		nil,   /* computedExpr */
 	)
 	for i := range desc.Columns {
@@ -1626,6 +3348,7 @@ func newOptVirtualTable(
 			d.Nullable,
 			d.Hidden,
 			d.DefaultExpr,
+			0, /* defaultSequenceID */
 			d.ComputeExpr,
 		)
 	}
@@ -1635,6 +3358,7 @@ func newOptVirtualTable(
 	for i := range ot.columns {
 		ot.colMap[descpb.ColumnID(ot.columns[i].ColID())] = i
 	}
+	ot.visibleCols = visibleColumnOrdinals(ot.columns)
 
 	ot.name.ExplicitSchema = true
 	ot.name.ExplicitCatalog = true
@@ -1702,6 +3426,28 @@ func (ot *optVirtualTable) Equals(other cat.Object) bool {
 	return true
 }
 
+// TargetDatabaseID returns the ID of the database that this instance of the
+// virtual table is scoped to, which is encoded in the upper 32 bits of its
+// stable ID (see the comment for optVirtualTable.id). It returns false if the
+// instance is not scoped to a database (e.g. "".information_schema.tables,
+// which contains information about all databases). If the instance is scoped
+// to a database that does not exist, it returns math.MaxUint32 and true.
+func (ot *optVirtualTable) TargetDatabaseID() (cat.StableID, bool) {
+	dbID := ot.id >> 32
+	if dbID == 0 {
+		return 0, false
+	}
+	return dbID, true
+}
+
+// IsUnimplemented returns true if the virtual table is a stub which never
+// produces any rows because populating it has not been implemented yet. Tables
+// which are empty because CockroachDB lacks the corresponding feature are not
+// considered unimplemented.
+func (ot *optVirtualTable) IsUnimplemented() bool {
+	return isUnimplementedVirtualTable(ot.desc)
+}
+
 // Name is part of the cat.Table interface.
 func (ot *optVirtualTable) Name() tree.Name {
 	return ot.name.ObjectName
@@ -1727,6 +3473,25 @@ func (ot *optVirtualTable) Column(i int) *cat.Column {
 	return &ot.columns[i]
 }
 
+// VisibleColumnCount is part of the cat.Table interface.
+func (ot *optVirtualTable) VisibleColumnCount() int {
+	return len(ot.visibleCols)
+}
+
+// VisibleColumn is part of the cat.Table interface.
+func (ot *optVirtualTable) VisibleColumn(i int) *cat.Column {
+	return &ot.columns[ot.visibleCols[i]]
+}
+
+// ColumnByID is part of the cat.Table interface.
+func (ot *optVirtualTable) ColumnByID(id cat.StableID) (_ *cat.Column, ok bool) {
+	ord, ok := ot.colMap[descpb.ColumnID(id)]
+	if !ok {
+		return nil, false
+	}
+	return &ot.columns[ord], true
+}
+
 // getColDesc is part of optCatalogTableInterface.
 func (ot *optVirtualTable) getColDesc(i int) *descpb.ColumnDescriptor {
 	if i > 0 && i <= len(ot.desc.Columns) {
@@ -1758,6 +3523,15 @@ func (ot *optVirtualTable) Index(i cat.IndexOrdinal) cat.Index {
 	return &ot.indexes[i]
 }
 
+// AllIndexes is part of the cat.Table interface.
+func (ot *optVirtualTable) AllIndexes() []cat.Index {
+	res := make([]cat.Index, len(ot.indexes))
+	for i := range ot.indexes {
+		res[i] = &ot.indexes[i]
+	}
+	return res
+}
+
 // StatisticCount is part of the cat.Table interface.
 func (ot *optVirtualTable) StatisticCount() int {
 	return 0
@@ -1768,6 +3542,31 @@ func (ot *optVirtualTable) Statistic(i int) cat.TableStatistic {
 	panic(errors.AssertionFailedf("no stats"))
 }
 
+// EstimatedRowCount is part of the cat.Table interface.
+func (ot *optVirtualTable) EstimatedRowCount() (rowCount uint64, ok bool) {
+	return virtualTableEstimatedRowCount(ot.desc.ID)
+}
+
+// virtualTableEstimatedRowCount returns the number of rows produced by the
+// virtual table with the given ID, for the virtual tables whose size doesn't
+// depend on the contents of the cluster. It returns false for all other
+// virtual tables.
+func virtualTableEstimatedRowCount(id descpb.ID) (rowCount uint64, ok bool) {
+	switch id {
+	case catconstants.CrdbInternalBuildInfoTableID:
+		// One row per build info field.
+		return 6, true
+	case catconstants.PgCatalogAmTableID:
+		// One row for forward indexes and one for inverted indexes.
+		return 2, true
+	case catconstants.CrdbInternalSessionVariablesTableID:
+		// One row per session variable.
+		return uint64(len(varNames)), true
+	default:
+		return 0, false
+	}
+}
+
 // CheckCount is part of the cat.Table interface.
 func (ot *optVirtualTable) CheckCount() int {
 	return len(ot.desc.ActiveChecks())
@@ -1787,6 +3586,11 @@ func (ot *optVirtualTable) FamilyCount() int {
 	return 1
 }
 
+// IsSingleFamily is part of the cat.Table interface.
+func (ot *optVirtualTable) IsSingleFamily() bool {
+	return ot.FamilyCount() == 1
+}
+
 // Family is part of the cat.Table interface.
 func (ot *optVirtualTable) Family(i int) cat.Family {
 	return &ot.family
@@ -1848,6 +3652,11 @@ func (oi *optVirtualIndex) Name() tree.Name {
 	return tree.Name(oi.desc.Name)
 }
 
+// IsPrimaryIndex is part of the cat.Index interface.
+func (oi *optVirtualIndex) IsPrimaryIndex() bool {
+	return oi.isPrimary
+}
+
 // IsUnique is part of the cat.Index interface.
 func (oi *optVirtualIndex) IsUnique() bool {
 	return oi.desc.Unique
@@ -1906,7 +3715,8 @@ func (oi *optVirtualIndex) Column(i int) cat.IndexColumn {
 	if i < length {
 		ord, _ := oi.tab.lookupColumnOrdinal(oi.desc.ColumnIDs[i])
 		return cat.IndexColumn{
-			Column: oi.tab.Column(ord),
+			Column:     oi.tab.Column(ord),
+			Descending: oi.desc.ColumnDirections[i] == descpb.IndexDescriptor_DESC,
 		}
 	}
 	if i == length {
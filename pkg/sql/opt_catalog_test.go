@@ -0,0 +1,3569 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/config/zonepb"
+	"github.com/cockroachdb/cockroach/pkg/jobs"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/catalogkeys"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/catalogkv"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/colinfo"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/tabledesc"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/typedesc"
+	"github.com/cockroachdb/cockroach/pkg/sql/opt/cat"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
+	"github.com/cockroachdb/cockroach/pkg/sql/sessiondatapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/stats"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
+	"github.com/gogo/protobuf/proto"
+	"github.com/lib/pq/oid"
+	"github.com/stretchr/testify/require"
+)
+
+// makeTestOptCatalog returns an optCatalog backed by an internal planner that
+// runs in a new transaction against the given test server. The returned
+// cleanup function must be called once the catalog is no longer needed.
+func makeTestOptCatalog(
+	ctx context.Context, s serverutils.TestServerInterface, kvDB *kv.DB,
+) (*optCatalog, func()) {
+	return makeTestOptCatalogForUser(ctx, s, kvDB, security.RootUserName())
+}
+
+// makeTestOptCatalogForUser is like makeTestOptCatalog, but the planner runs
+// as the given user.
+func makeTestOptCatalogForUser(
+	ctx context.Context, s serverutils.TestServerInterface, kvDB *kv.DB, user security.SQLUsername,
+) (*optCatalog, func()) {
+	execCfg := s.ExecutorConfig().(ExecutorConfig)
+	internalPlanner, cleanup := NewInternalPlanner(
+		"test",
+		kv.NewTxn(ctx, kvDB, s.NodeID()),
+		user,
+		&MemoryMetrics{},
+		&execCfg,
+		sessiondatapb.SessionData{},
+	)
+	oc := &optCatalog{}
+	oc.init(internalPlanner.(*planner))
+	oc.reset()
+	return oc, cleanup
+}
+
+// optCatalogTestServer is a test server together with an optCatalog that
+// reads from it. It is used by tests that need a real catalog, for example to
+// resolve names, types or other descriptors; tests that only inspect a single
+// table descriptor should use makeTestTableDesc and makeTestOptTable instead.
+type optCatalogTestServer struct {
+	s    serverutils.TestServerInterface
+	kvDB *kv.DB
+	r    *sqlutils.SQLRunner
+	oc   *optCatalog
+}
+
+// startOptCatalogTestServer starts a test server, runs the given setup
+// statements and creates an optCatalog against it. The returned cleanup
+// function releases the catalog and stops the server.
+func startOptCatalogTestServer(t testing.TB, setup string) (*optCatalogTestServer, func()) {
+	ctx := context.Background()
+	s, sqlDB, kvDB := serverutils.StartServer(t, base.TestServerArgs{})
+	r := sqlutils.MakeSQLRunner(sqlDB)
+	if setup != "" {
+		r.Exec(t, setup)
+	}
+	oc, cleanupCatalog := makeTestOptCatalog(ctx, s, kvDB)
+	ts := &optCatalogTestServer{s: s, kvDB: kvDB, r: r, oc: oc}
+	return ts, func() {
+		cleanupCatalog()
+		s.Stopper().Stop(ctx)
+	}
+}
+
+// resolve resolves the data source with the given name in the public schema of
+// database t, without table statistics.
+func (ts *optCatalogTestServer) resolve(t testing.TB, name tree.Name) cat.DataSource {
+	return ts.resolveInSchema(t, tree.PublicSchemaName, name)
+}
+
+// resolveInSchema is like resolve, but for a data source in the given schema.
+func (ts *optCatalogTestServer) resolveInSchema(
+	t testing.TB, schema, name tree.Name,
+) cat.DataSource {
+	tn := tree.MakeTableNameWithSchema("t", schema, name)
+	ds, _, err := ts.oc.ResolveDataSource(context.Background(), cat.Flags{NoTableStats: true}, &tn)
+	require.NoError(t, err)
+	return ds
+}
+
+// makeTestTableDesc returns a table descriptor created from the given CREATE
+// TABLE statement, without needing a running server.
+func makeTestTableDesc(t testing.TB, schema string) *tabledesc.Mutable {
+	desc, err := CreateTestTableDescriptor(
+		context.Background(), 1 /* parentID */, 100 /* id */, schema,
+		descpb.NewDefaultPrivilegeDescriptor(security.AdminRoleName()),
+	)
+	require.NoError(t, err)
+	return desc
+}
+
+// makeTestIndexMutation turns the public secondary index with the given name
+// into an index mutation with the given state and direction.
+func makeTestIndexMutation(
+	t testing.TB,
+	desc *tabledesc.Mutable,
+	name string,
+	state descpb.DescriptorMutation_State,
+	direction descpb.DescriptorMutation_Direction,
+) {
+	for i := range desc.Indexes {
+		if desc.Indexes[i].Name == name {
+			idx := desc.Indexes[i]
+			desc.Indexes = append(desc.Indexes[:i:i], desc.Indexes[i+1:]...)
+			desc.Mutations = append(desc.Mutations, descpb.DescriptorMutation{
+				Descriptor_: &descpb.DescriptorMutation_Index{Index: &idx},
+				State:       state,
+				Direction:   direction,
+			})
+			return
+		}
+	}
+	t.Fatalf("index %q not found", name)
+}
+
+// makeTestOptTable wraps the given table descriptor in an optTable without
+// any statistics or zone configuration.
+func makeTestOptTable(t testing.TB, desc *tabledesc.Mutable) *optTable {
+	ot, err := newOptTable(
+		tabledesc.NewImmutable(*desc.TableDesc()),
+		keys.SystemSQLCodec,
+		&cluster.MakeTestingClusterSettings().SV,
+		nil, /* stats */
+		emptyZoneConfig,
+	)
+	require.NoError(t, err)
+	return ot
+}
+
+func TestOptCatalogRegClass(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	srv, cleanup := startOptCatalogTestServer(t, `
+		CREATE DATABASE t;
+		CREATE TABLE t.kv (k INT PRIMARY KEY, v INT);
+		CREATE VIEW t.kv_view AS SELECT k FROM t.kv;
+	`)
+	defer cleanup()
+
+	for _, name := range []string{"kv", "kv_view"} {
+		t.Run(name, func(t *testing.T) {
+			tn := tree.MakeTableNameWithSchema("t", tree.PublicSchemaName, tree.Name(name))
+			regclass, err := srv.oc.RegClass(ctx, &tn)
+			require.NoError(t, err)
+
+			desc := catalogkv.TestingGetTableDescriptor(srv.kvDB, keys.SystemSQLCodec, "t", name)
+			require.Equal(t, oid.Oid(desc.GetID()), regclass)
+
+			var expected int
+			srv.r.QueryRow(t, fmt.Sprintf("SELECT 't.%s'::REGCLASS::OID", name)).Scan(&expected)
+			require.Equal(t, oid.Oid(expected), regclass)
+		})
+	}
+
+	t.Run("missing", func(t *testing.T) {
+		tn := tree.MakeTableNameWithSchema("t", tree.PublicSchemaName, "missing")
+		_, err := srv.oc.RegClass(ctx, &tn)
+		require.Error(t, err)
+		require.Equal(t, pgcode.UndefinedTable, pgerror.GetPGCode(err))
+	})
+}
+
+func TestOptCatalogResolveDataSourceByOID(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	srv, cleanup := startOptCatalogTestServer(t, `
+		CREATE DATABASE t;
+		CREATE TABLE t.kv (k INT PRIMARY KEY, v INT);
+		CREATE VIEW t.kv_view AS SELECT k FROM t.kv;
+		CREATE SEQUENCE t.kv_seq;
+	`)
+	defer cleanup()
+
+	for _, name := range []string{"kv", "kv_view", "kv_seq"} {
+		t.Run(name, func(t *testing.T) {
+			tn := tree.MakeTableNameWithSchema("t", tree.PublicSchemaName, tree.Name(name))
+			regclass, err := srv.oc.RegClass(ctx, &tn)
+			require.NoError(t, err)
+
+			ds, err := srv.oc.ResolveDataSourceByOID(ctx, cat.Flags{}, regclass)
+			require.NoError(t, err)
+			require.Equal(t, tree.Name(name), ds.Name())
+			require.Equal(t, regclass, oid.Oid(ds.PostgresDescriptorID()))
+
+			var relname string
+			srv.r.QueryRow(t,
+				"SELECT relname FROM t.pg_catalog.pg_class WHERE oid = $1", regclass,
+			).Scan(&relname)
+			require.Equal(t, name, relname)
+		})
+	}
+
+	for _, o := range []oid.Oid{0, 12345678} {
+		t.Run(fmt.Sprintf("missing-%d", o), func(t *testing.T) {
+			_, err := srv.oc.ResolveDataSourceByOID(ctx, cat.Flags{}, o)
+			require.Error(t, err)
+			require.Equal(t, pgcode.UndefinedTable, pgerror.GetPGCode(err))
+		})
+	}
+}
+
+func TestOptIndexIsValid(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	desc := makeTestTableDesc(t, `
+		CREATE TABLE t (
+			a INT PRIMARY KEY,
+			b INT,
+			INDEX public_partial (b) WHERE b > 0,
+			INDEX backfilling_partial (b) WHERE b < 0
+		)
+	`)
+	// Turn backfilling_partial into an index mutation, as if it was still being
+	// added to the table.
+	makeTestIndexMutation(
+		t, desc, "backfilling_partial", descpb.DescriptorMutation_DELETE_AND_WRITE_ONLY, descpb.DescriptorMutation_ADD,
+	)
+	ot := makeTestOptTable(t, desc)
+
+	expected := map[tree.Name]bool{
+		"primary":             true,
+		"public_partial":      true,
+		"backfilling_partial": false,
+	}
+	require.Equal(t, len(expected), ot.DeletableIndexCount())
+	for i := 0; i < ot.DeletableIndexCount(); i++ {
+		idx := ot.Index(i).(*optIndex)
+		require.Equal(t, expected[idx.Name()], idx.IsValid(), "index %s", idx.Name())
+	}
+}
+
+func TestOptIndexCoveredColumns(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ot := makeTestOptTable(t, makeTestTableDesc(t, `
+		CREATE TABLE t (
+			a INT PRIMARY KEY,
+			b INT,
+			c INT,
+			d INT,
+			INDEX b_idx (b),
+			INDEX c_idx (c) STORING (d)
+		)
+	`))
+
+	// Column ordinals are a=0, b=1, c=2, d=3, followed by system columns.
+	testCases := []struct {
+		index    cat.IndexOrdinal
+		expected util.FastIntSet
+	}{
+		{index: cat.PrimaryIndex, expected: util.MakeFastIntSet(0, 1, 2, 3)},
+		{index: 1, expected: util.MakeFastIntSet(1, 0)},
+		{index: 2, expected: util.MakeFastIntSet(2, 0, 3)},
+	}
+	for _, tc := range testCases {
+		idx := ot.Index(tc.index).(*optIndex)
+		t.Run(string(idx.Name()), func(t *testing.T) {
+			// The primary index also covers the system columns.
+			expected := tc.expected.Copy()
+			if tc.index == cat.PrimaryIndex {
+				for i := 4; i < ot.ColumnCount(); i++ {
+					expected.Add(i)
+				}
+			}
+			require.Equal(t, expected.String(), idx.CoveredColumns().String())
+		})
+	}
+}
+
+func BenchmarkOptIndexCoveredColumns(b *testing.B) {
+	defer leaktest.AfterTest(b)()
+	defer log.Scope(b).Close(b)
+
+	ot := makeTestOptTable(b, makeTestTableDesc(b, `
+		CREATE TABLE t (
+			a INT PRIMARY KEY,
+			b INT,
+			c INT,
+			d INT,
+			e INT,
+			INDEX b_idx (b),
+			INDEX c_idx (c) STORING (d),
+			INDEX de_idx (d, e)
+		)
+	`))
+	needed := util.MakeFastIntSet(0, 2, 3)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < ot.IndexCount(); j++ {
+			_ = needed.SubsetOf(ot.Index(j).(*optIndex).CoveredColumns())
+		}
+	}
+}
+
+func TestOptTablePrimaryKeySharded(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	testCases := []struct {
+		name    string
+		sharded descpb.ShardedDescriptor
+		buckets int
+	}{
+		{
+			name: "sharded",
+			sharded: descpb.ShardedDescriptor{
+				IsSharded:    true,
+				Name:         "crdb_internal_k_shard_8",
+				ShardBuckets: 8,
+				ColumnNames:  []string{"k"},
+			},
+			buckets: 8,
+		},
+		{name: "normal", buckets: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			desc := makeTestTableDesc(t, `CREATE TABLE t (k INT PRIMARY KEY, v INT)`)
+			// Creating a hash-sharded index requires a session setting, so the
+			// primary index is marked as sharded directly.
+			desc.PrimaryIndex.Sharded = tc.sharded
+			ot := makeTestOptTable(t, desc)
+			require.Equal(t, tc.sharded.IsSharded, ot.IsPrimaryKeySharded())
+			require.Equal(t, tc.buckets, ot.PrimaryKeyShardBuckets())
+		})
+	}
+}
+
+func TestOptCatalogResolveIndex(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	srv, cleanup := startOptCatalogTestServer(t, `
+		CREATE DATABASE t;
+		CREATE TABLE t.kv (k INT PRIMARY KEY, v INT, INDEX v_idx (v));
+	`)
+	defer cleanup()
+
+	t.Run("valid", func(t *testing.T) {
+		tn := tree.MakeTableNameWithSchema("t", tree.PublicSchemaName, "kv")
+		tab, idx, err := srv.oc.ResolveIndex(ctx, &tn, "v_idx")
+		require.NoError(t, err)
+		require.Equal(t, tree.Name("kv"), tab.Name())
+		require.Equal(t, tree.Name("v_idx"), idx.Name())
+		require.Equal(t, tab, idx.Table())
+	})
+
+	t.Run("unknown index", func(t *testing.T) {
+		tn := tree.MakeTableNameWithSchema("t", tree.PublicSchemaName, "kv")
+		_, _, err := srv.oc.ResolveIndex(ctx, &tn, "missing_idx")
+		require.Error(t, err)
+		require.Equal(t, pgcode.UndefinedObject, pgerror.GetPGCode(err))
+		require.Contains(t, err.Error(), `index "missing_idx" does not exist`)
+	})
+
+	t.Run("unknown table", func(t *testing.T) {
+		tn := tree.MakeTableNameWithSchema("t", tree.PublicSchemaName, "missing")
+		_, _, err := srv.oc.ResolveIndex(ctx, &tn, "v_idx")
+		require.Error(t, err)
+		require.Equal(t, pgcode.UndefinedTable, pgerror.GetPGCode(err))
+	})
+}
+
+func TestOptSchemaOwner(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	srv, cleanup := startOptCatalogTestServer(t, `
+		CREATE DATABASE t;
+		CREATE USER testuser;
+		CREATE SCHEMA t.sc AUTHORIZATION testuser;
+	`)
+	defer cleanup()
+
+	var schemaID int
+	srv.r.QueryRow(t, `
+		SELECT id FROM system.namespace
+		WHERE name = 'sc' AND "parentSchemaID" = 0
+		AND "parentID" = (SELECT id FROM system.namespace WHERE name = 't' AND "parentID" = 0)
+	`).Scan(&schemaID)
+
+	resolveSchema := func(t *testing.T, name string) *optSchema {
+		sn := cat.SchemaName{
+			CatalogName:     "t",
+			SchemaName:      tree.Name(name),
+			ExplicitCatalog: true,
+			ExplicitSchema:  true,
+		}
+		sc, _, err := srv.oc.ResolveSchema(ctx, cat.Flags{}, &sn)
+		require.NoError(t, err)
+		return sc.(*optSchema)
+	}
+
+	t.Run("user-defined", func(t *testing.T) {
+		sc := resolveSchema(t, "sc")
+		require.Equal(t, cat.StableID(schemaID), sc.ID())
+		require.Equal(t, security.TestUserName(), sc.Owner())
+	})
+
+	t.Run("public", func(t *testing.T) {
+		sc := resolveSchema(t, tree.PublicSchema)
+		require.Equal(t, security.AdminRoleName(), sc.Owner())
+	})
+}
+
+func TestOptCatalogDescriptorModificationTime(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	s, sqlDB, kvDB := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(ctx)
+
+	r := sqlutils.MakeSQLRunner(sqlDB)
+	r.Exec(t, `
+		CREATE DATABASE t;
+		CREATE TABLE t.kv (k INT PRIMARY KEY, v INT);
+	`)
+
+	tn := tree.MakeTableNameWithSchema("t", tree.PublicSchemaName, "kv")
+	modificationTime := func() hlc.Timestamp {
+		oc, cleanup := makeTestOptCatalog(ctx, s, kvDB)
+		defer cleanup()
+		ds, _, err := oc.ResolveDataSource(ctx, cat.Flags{NoTableStats: true}, &tn)
+		require.NoError(t, err)
+		ts, err := oc.DescriptorModificationTime(ds)
+		require.NoError(t, err)
+		require.False(t, ts.IsEmpty())
+		return ts
+	}
+
+	before := modificationTime()
+	require.Equal(t, before, modificationTime())
+
+	r.Exec(t, `ALTER TABLE t.kv ADD COLUMN w INT`)
+	after := modificationTime()
+	require.True(t, before.Less(after), "expected %s < %s", before, after)
+}
+
+func TestOptIndexKeyColumnDescending(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ot := makeTestOptTable(t, makeTestTableDesc(t, `
+		CREATE TABLE t (
+			a INT,
+			b INT,
+			c INT,
+			PRIMARY KEY (a DESC, b),
+			INDEX c_idx (c DESC)
+		)
+	`))
+
+	testCases := []struct {
+		index    cat.IndexOrdinal
+		expected []bool
+	}{
+		// The primary index key is (a DESC, b).
+		{index: cat.PrimaryIndex, expected: []bool{true, false}},
+		// The secondary index key is (c DESC), followed by the primary key suffix
+		// (a, b). The suffix is always encoded in ascending order.
+		{index: 1, expected: []bool{true, false, false}},
+	}
+	for _, tc := range testCases {
+		idx := ot.Index(tc.index).(*optIndex)
+		t.Run(string(idx.Name()), func(t *testing.T) {
+			require.Equal(t, len(tc.expected), idx.KeyColumnCount())
+			for i, expected := range tc.expected {
+				require.Equal(t, expected, idx.KeyColumnDescending(i), "column %d", i)
+			}
+		})
+	}
+}
+
+// TestOptIndexExtraColumnDirections verifies that the primary key suffix of a
+// secondary index is reported as ascending even when the primary key is
+// descending, since that is how the suffix is encoded.
+func TestOptIndexExtraColumnDirections(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	tab := makeTestOptTable(t, makeTestTableDesc(t, `
+		CREATE TABLE t (
+			a INT,
+			b INT,
+			c INT,
+			PRIMARY KEY (a DESC, b DESC),
+			INDEX c_idx (c DESC)
+		)
+	`))
+
+	testCases := []struct {
+		index      cat.IndexOrdinal
+		cols       []tree.Name
+		descending []bool
+	}{
+		{index: cat.PrimaryIndex, cols: []tree.Name{"a", "b"}, descending: []bool{true, true}},
+		{index: 1, cols: []tree.Name{"c", "a", "b"}, descending: []bool{true, false, false}},
+	}
+	for _, tc := range testCases {
+		idx := tab.Index(tc.index)
+		t.Run(string(idx.Name()), func(t *testing.T) {
+			var cols []tree.Name
+			var descending []bool
+			for i := 0; i < idx.KeyColumnCount(); i++ {
+				cols = append(cols, idx.Column(i).ColName())
+				descending = append(descending, idx.Column(i).Descending)
+			}
+			require.Equal(t, tc.cols, cols)
+			require.Equal(t, tc.descending, descending)
+		})
+	}
+}
+
+func TestOptTableAllIndexes(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	desc := makeTestTableDesc(t, `
+		CREATE TABLE t (
+			a INT PRIMARY KEY,
+			b INT,
+			c INT,
+			d INT,
+			INDEX b_idx (b),
+			INDEX c_idx (c),
+			INDEX d_idx (d)
+		)
+	`)
+	makeTestIndexMutation(
+		t, desc, "c_idx", descpb.DescriptorMutation_DELETE_AND_WRITE_ONLY, descpb.DescriptorMutation_ADD,
+	)
+	makeTestIndexMutation(
+		t, desc, "b_idx", descpb.DescriptorMutation_DELETE_ONLY, descpb.DescriptorMutation_DROP,
+	)
+	ot := makeTestOptTable(t, desc)
+
+	// Public indexes come first, followed by the write-only and then the
+	// delete-only mutation indexes, in ordinal order.
+	var names []string
+	for _, idx := range ot.AllIndexes() {
+		names = append(names, string(idx.Name()))
+	}
+	require.Equal(t, []string{"primary", "d_idx", "c_idx", "b_idx"}, names)
+}
+
+func TestOptTableIsIndexed(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	desc := makeTestTableDesc(t, `
+		CREATE TABLE t (
+			a INT PRIMARY KEY,
+			b INT,
+			c INT,
+			d INT,
+			e INT,
+			f INT,
+			INDEX b_idx (b) STORING (c),
+			INDEX e_idx (e),
+			INDEX a_idx (a) STORING (f)
+		)
+	`)
+	// Indexes that are not public don't count.
+	makeTestIndexMutation(
+		t, desc, "e_idx", descpb.DescriptorMutation_DELETE_AND_WRITE_ONLY, descpb.DescriptorMutation_ADD,
+	)
+	makeTestIndexMutation(
+		t, desc, "a_idx", descpb.DescriptorMutation_DELETE_AND_WRITE_ONLY, descpb.DescriptorMutation_ADD,
+	)
+	ot := makeTestOptTable(t, desc)
+
+	testCases := []struct {
+		col     string
+		indexed bool
+	}{
+		{col: "a", indexed: true},
+		{col: "b", indexed: true},
+		// STORING columns of public secondary indexes count.
+		{col: "c", indexed: true},
+		// Columns that are only stored in the primary index don't count.
+		{col: "d", indexed: false},
+		{col: "e", indexed: false},
+		{col: "f", indexed: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.col, func(t *testing.T) {
+			found := false
+			for i := 0; i < ot.ColumnCount(); i++ {
+				if ot.Column(i).ColName() == tree.Name(tc.col) {
+					require.Equal(t, tc.indexed, ot.IsIndexed(i))
+					found = true
+				}
+			}
+			require.True(t, found)
+		})
+	}
+}
+
+func TestOptTableIsPartitioningColumn(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	const schema = `
+		CREATE TABLE t (
+			region STRING,
+			zone STRING,
+			k INT,
+			v INT,
+			w INT,
+			PRIMARY KEY (region, zone, k),
+			INDEX v_idx (v),
+			INDEX w_idx (w)
+		)
+	`
+
+	// Partitioning requires a CCL license to set up through SQL, so the
+	// partitioning descriptors are crafted directly.
+	desc := makeTestTableDesc(t, schema)
+	desc.PrimaryIndex.Partitioning = descpb.PartitioningDescriptor{
+		NumColumns: 1,
+		List: []descpb.PartitioningDescriptor_List{
+			{Name: "us", Subpartitioning: descpb.PartitioningDescriptor{
+				NumColumns: 1,
+				List:       []descpb.PartitioningDescriptor_List{{Name: "us_east"}},
+			}},
+			{Name: "eu"},
+		},
+	}
+	desc.Indexes[0].Partitioning = descpb.PartitioningDescriptor{
+		NumColumns: 1,
+		Range:      []descpb.PartitioningDescriptor_Range{{Name: "small"}},
+	}
+	// Partitioning of an index that is not public doesn't count.
+	desc.Indexes[1].Partitioning = descpb.PartitioningDescriptor{
+		NumColumns: 1,
+		List:       []descpb.PartitioningDescriptor_List{{Name: "p"}},
+	}
+	makeTestIndexMutation(
+		t, desc, "w_idx", descpb.DescriptorMutation_DELETE_AND_WRITE_ONLY, descpb.DescriptorMutation_ADD,
+	)
+	ot := makeTestOptTable(t, desc)
+
+	unpartitioned := makeTestOptTable(t, makeTestTableDesc(t, schema))
+
+	testCases := []struct {
+		col         int
+		partitioned bool
+	}{
+		{col: 0 /* region */, partitioned: true},
+		// Subpartitioning column of the primary index.
+		{col: 1 /* zone */, partitioned: true},
+		{col: 2 /* k */, partitioned: false},
+		{col: 3 /* v */, partitioned: true},
+		{col: 4 /* w */, partitioned: false},
+	}
+	for _, tc := range testCases {
+		t.Run(string(ot.Column(tc.col).ColName()), func(t *testing.T) {
+			require.Equal(t, tc.partitioned, ot.IsPartitioningColumn(tc.col))
+			require.False(t, unpartitioned.IsPartitioningColumn(tc.col))
+		})
+	}
+}
+
+func TestOptVirtualIndexDescending(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	desc := makeTestTableDesc(t, `
+		CREATE TABLE t (
+			a INT,
+			b INT,
+			INDEX a_idx (a ASC),
+			INDEX b_idx (b DESC)
+		)
+	`)
+	// The table name has no catalog, so the optCatalog is not needed to
+	// compute the virtual table's ID.
+	tn := tree.MakeTableNameWithSchema("", "crdb_internal", "t")
+	ot, err := newOptVirtualTable(
+		context.Background(), nil /* oc */, tabledesc.NewImmutable(*desc.TableDesc()), &tn,
+	)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		index      cat.IndexOrdinal
+		col        tree.Name
+		descending bool
+	}{
+		{index: 1, col: "a", descending: false},
+		{index: 2, col: "b", descending: true},
+	}
+	for _, tc := range testCases {
+		idx := ot.Index(tc.index)
+		t.Run(string(idx.Name()), func(t *testing.T) {
+			require.Equal(t, tc.col, idx.Column(0).ColName())
+			require.Equal(t, tc.descending, idx.Column(0).Descending)
+			// The dummy PK column that follows is always ascending.
+			require.Equal(t, cat.StableID(math.MaxInt64), idx.Column(1).ColID())
+			require.False(t, idx.Column(1).Descending)
+		})
+	}
+}
+
+func TestOptTableEstimatedRowCount(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	srv, cleanup := startOptCatalogTestServer(t, `
+		CREATE DATABASE t;
+		CREATE TABLE t.kv (k INT PRIMARY KEY, v INT);
+	`)
+	defer cleanup()
+
+	testCases := []struct {
+		schema, table string
+		ok            bool
+	}{
+		{schema: "crdb_internal", table: "node_build_info", ok: true},
+		{schema: "crdb_internal", table: "session_variables", ok: true},
+		{schema: "pg_catalog", table: "pg_am", ok: true},
+		{schema: "crdb_internal", table: "tables", ok: false},
+		{schema: "public", table: "kv", ok: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.table, func(t *testing.T) {
+			ds := srv.resolveInSchema(t, tree.Name(tc.schema), tree.Name(tc.table))
+			rowCount, ok := ds.(cat.Table).EstimatedRowCount()
+			require.Equal(t, tc.ok, ok)
+			if !ok {
+				return
+			}
+			var expected uint64
+			query := fmt.Sprintf("SELECT count(*) FROM t.%s.%s", tc.schema, tc.table)
+			srv.r.QueryRow(t, query).Scan(&expected)
+			require.Equal(t, expected, rowCount)
+		})
+	}
+}
+
+func TestOptVirtualTableTargetDatabaseID(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	srv, cleanup := startOptCatalogTestServer(t, `CREATE DATABASE t`)
+	defer cleanup()
+
+	dbID := catalogkv.TestingGetDatabaseDescriptor(srv.kvDB, keys.SystemSQLCodec, "t").GetID()
+
+	testCases := []struct {
+		name     string
+		catalog  tree.Name
+		expID    cat.StableID
+		expFound bool
+	}{
+		{name: "all databases", catalog: "", expID: 0, expFound: false},
+		{name: "specific database", catalog: "t", expID: cat.StableID(dbID), expFound: true},
+		{name: "nonexistent database", catalog: "missing", expID: math.MaxUint32, expFound: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tn := tree.MakeTableNameWithSchema(tc.catalog, "information_schema", "tables")
+			ds, _, err := srv.oc.ResolveDataSource(ctx, cat.Flags{}, &tn)
+			require.NoError(t, err)
+			id, found := ds.(*optVirtualTable).TargetDatabaseID()
+			require.Equal(t, tc.expFound, found)
+			require.Equal(t, tc.expID, id)
+		})
+	}
+}
+
+func TestOptTableSynthesizedChecks(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	srv, cleanup := startOptCatalogTestServer(t, `
+		CREATE DATABASE t;
+		CREATE TYPE t.greeting AS ENUM ('hello', 'hi');
+		CREATE TABLE t.tab (k INT PRIMARY KEY CHECK (k > 0), g t.greeting);
+	`)
+	defer cleanup()
+
+	tab := srv.resolve(t, "tab").(cat.Table)
+
+	require.Equal(t, 2, tab.CheckCount())
+	var user, synthesized []string
+	for i := 0; i < tab.CheckCount(); i++ {
+		check := tab.Check(i)
+		require.True(t, check.Validated)
+		if check.Synthesized {
+			synthesized = append(synthesized, check.Constraint)
+		} else {
+			user = append(user, check.Constraint)
+		}
+	}
+	require.Equal(t, []string{"k > 0"}, user)
+	require.Len(t, synthesized, 1)
+	require.Contains(t, synthesized[0], "g IN")
+}
+
+func TestOptForeignKeyConstraintColumnNames(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	srv, cleanup := startOptCatalogTestServer(t, `
+		CREATE DATABASE t;
+		CREATE TABLE t.parent (p INT, a INT, b INT, PRIMARY KEY (a, b));
+		CREATE TABLE t.child (
+			c INT PRIMARY KEY,
+			x INT,
+			y INT,
+			CONSTRAINT fk FOREIGN KEY (y, x) REFERENCES t.parent (a, b)
+		);
+	`)
+	defer cleanup()
+
+	parent, child := srv.resolve(t, "parent").(cat.Table), srv.resolve(t, "child").(cat.Table)
+
+	check := func(t *testing.T, fk *optForeignKeyConstraint) {
+		require.Equal(t, 2, fk.ColumnCount())
+		var origin, referenced []tree.Name
+		for i := 0; i < fk.ColumnCount(); i++ {
+			origin = append(origin, fk.OriginColumnName(child, i))
+			referenced = append(referenced, fk.ReferencedColumnName(parent, i))
+		}
+		require.Equal(t, []tree.Name{"y", "x"}, origin)
+		require.Equal(t, []tree.Name{"a", "b"}, referenced)
+
+		// Passing the wrong table is an assertion failure.
+		require.Panics(t, func() { fk.OriginColumnName(parent, 0) })
+		require.Panics(t, func() { fk.ReferencedColumnName(child, 0) })
+	}
+
+	t.Run("outbound", func(t *testing.T) {
+		require.Equal(t, 1, child.OutboundForeignKeyCount())
+		check(t, child.OutboundForeignKey(0).(*optForeignKeyConstraint))
+	})
+
+	t.Run("inbound", func(t *testing.T) {
+		require.Equal(t, 1, parent.InboundForeignKeyCount())
+		check(t, parent.InboundForeignKey(0).(*optForeignKeyConstraint))
+	})
+}
+
+func TestOptCatalogResolveDatabase(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	srv, cleanup := startOptCatalogTestServer(t, `CREATE DATABASE t`)
+	defer cleanup()
+
+	t.Run("existing", func(t *testing.T) {
+		db, err := srv.oc.ResolveDatabase(ctx, "t")
+		require.NoError(t, err)
+		expected := catalogkv.TestingGetDatabaseDescriptor(srv.kvDB, keys.SystemSQLCodec, "t")
+		require.Equal(t, cat.StableID(expected.GetID()), db.ID())
+		require.Equal(t, tree.Name("t"), db.(*optDatabase).Name())
+
+		// The database can be used for privilege checks.
+		require.NoError(t, srv.oc.CheckPrivilege(ctx, db, privilege.CREATE))
+
+		other, err := srv.oc.ResolveDatabase(ctx, "t")
+		require.NoError(t, err)
+		require.True(t, db.Equals(other))
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		_, err := srv.oc.ResolveDatabase(ctx, "missing")
+		require.Error(t, err)
+		require.Equal(t, pgcode.UndefinedDatabase, pgerror.GetPGCode(err))
+	})
+}
+
+func TestOptIndexStorageParams(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	desc := makeTestTableDesc(t, `
+		CREATE TABLE t (
+			k INT PRIMARY KEY,
+			v INT,
+			g GEOMETRY,
+			INDEX plain_idx (v),
+			INDEX sharded_idx (v),
+			INVERTED INDEX geom_idx (g) WITH (
+				s2_max_level = 20, geometry_min_x = 0, geometry_max_x = 100,
+				geometry_min_y = 0, geometry_max_y = 100
+			)
+		)
+	`)
+	// Creating a hash-sharded index requires a session setting, so sharded_idx
+	// is marked as sharded directly.
+	for i := range desc.Indexes {
+		if desc.Indexes[i].Name == "sharded_idx" {
+			desc.Indexes[i].Sharded = descpb.ShardedDescriptor{
+				IsSharded:    true,
+				Name:         "crdb_internal_v_shard_8",
+				ShardBuckets: 8,
+				ColumnNames:  []string{"v"},
+			}
+		}
+	}
+	ot := makeTestOptTable(t, desc)
+	indexes := make(map[tree.Name]*optIndex, ot.IndexCount())
+	for i := 0; i < ot.IndexCount(); i++ {
+		indexes[ot.Index(i).Name()] = ot.Index(i).(*optIndex)
+	}
+
+	testCases := []struct {
+		index    tree.Name
+		expected map[string]string
+	}{
+		{index: "plain_idx", expected: nil},
+		{index: "sharded_idx", expected: map[string]string{"bucket_count": "8"}},
+		{index: "geom_idx", expected: map[string]string{
+			"s2_max_level":   "20",
+			"s2_level_mod":   "1",
+			"s2_max_cells":   "4",
+			"geometry_min_x": "0",
+			"geometry_max_x": "100",
+			"geometry_min_y": "0",
+			"geometry_max_y": "100",
+		}},
+	}
+	for _, tc := range testCases {
+		t.Run(string(tc.index), func(t *testing.T) {
+			idx, ok := indexes[tc.index]
+			require.True(t, ok)
+			require.Equal(t, tc.expected, idx.StorageParams())
+		})
+	}
+}
+
+func TestOptCatalogResolveDataSourcePrefix(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	srv, cleanup := startOptCatalogTestServer(t, `
+		CREATE DATABASE t;
+		CREATE SCHEMA t.sc1;
+		CREATE SCHEMA t.sc2;
+		CREATE TABLE t.sc1.x (k INT PRIMARY KEY);
+		CREATE TABLE t.sc2.x (k INT PRIMARY KEY);
+		CREATE TABLE t.public.y (k INT PRIMARY KEY);
+	`)
+	defer cleanup()
+	srv.oc.planner.SessionData().Database = "t"
+
+	testCases := []struct {
+		searchPath []string
+		name       tree.Name
+		expected   tree.Name
+		found      bool
+	}{
+		{searchPath: []string{"sc1", "sc2"}, name: "x", expected: "sc1", found: true},
+		{searchPath: []string{"sc2", "sc1"}, name: "x", expected: "sc2", found: true},
+		{searchPath: []string{"sc2", "public"}, name: "y", expected: "public", found: true},
+		{searchPath: []string{"public"}, name: "x", found: false},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("%s/%v", tc.name, tc.searchPath), func(t *testing.T) {
+			srv.oc.planner.SessionData().SearchPath = sessiondata.MakeSearchPath(tc.searchPath)
+			tn := tree.MakeUnqualifiedTableName(tc.name)
+			prefix, found, err := srv.oc.ResolveDataSourcePrefix(ctx, &tn)
+			require.NoError(t, err)
+			require.Equal(t, tc.found, found)
+			if found {
+				require.Equal(t, tree.Name("t"), prefix.CatalogName)
+				require.Equal(t, tc.expected, prefix.SchemaName)
+			}
+		})
+	}
+}
+
+func TestOptTableDistinctCountForColumns(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	desc := makeTestTableDesc(t, `CREATE TABLE t (a INT PRIMARY KEY, b INT, c INT, d INT)`)
+	makeStat := func(distinctCount uint64, createdAt int64, colIDs ...descpb.ColumnID) *stats.TableStatistic {
+		return &stats.TableStatistic{TableStatisticProto: stats.TableStatisticProto{
+			ColumnIDs:     colIDs,
+			CreatedAt:     timeutil.Unix(createdAt, 0),
+			RowCount:      1000,
+			DistinctCount: distinctCount,
+		}}
+	}
+	// Column IDs 1-4 correspond to ordinals 0-3. As in the stats cache, the
+	// statistics are ordered from most to least recent.
+	ot, err := newOptTable(
+		tabledesc.NewImmutable(*desc.TableDesc()),
+		keys.SystemSQLCodec,
+		&cluster.MakeTestingClusterSettings().SV,
+		[]*stats.TableStatistic{
+			makeStat(10, 3, 1),
+			makeStat(20, 3, 2),
+			makeStat(25, 2, 2),
+			makeStat(200, 3, 3, 2),
+			makeStat(900, 3, 1, 2, 4),
+		},
+		emptyZoneConfig,
+	)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		cols     []int
+		expected uint64
+		ok       bool
+	}{
+		// Single-column statistics; the most recent one wins.
+		{cols: []int{0}, expected: 10, ok: true},
+		{cols: []int{1}, expected: 20, ok: true},
+		// Multi-column statistics match regardless of column order.
+		{cols: []int{1, 2}, expected: 200, ok: true},
+		{cols: []int{2, 1}, expected: 200, ok: true},
+		{cols: []int{3, 1, 0}, expected: 900, ok: true},
+		// Prefix matches use the statistic on the longest matching prefix.
+		{cols: []int{1, 3}, expected: 20, ok: true},
+		{cols: []int{1, 2, 3}, expected: 200, ok: true},
+		{cols: []int{0, 2}, expected: 10, ok: true},
+		// No statistic on the leading column.
+		{cols: []int{2}, ok: false},
+		{cols: []int{3, 0}, ok: false},
+		{cols: nil, ok: false},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprint(tc.cols), func(t *testing.T) {
+			distinctCount, ok := ot.DistinctCountForColumns(tc.cols)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.expected, distinctCount)
+		})
+	}
+}
+
+func TestOptTableCascadingInboundForeignKeys(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	srv, cleanup := startOptCatalogTestServer(t, `
+		CREATE DATABASE t;
+		CREATE TABLE t.parent (p INT PRIMARY KEY);
+		CREATE TABLE t.no_action (k INT PRIMARY KEY, p INT REFERENCES t.parent (p));
+		CREATE TABLE t.restrict (
+			k INT PRIMARY KEY, p INT REFERENCES t.parent (p) ON DELETE RESTRICT
+		);
+		CREATE TABLE t.delete_cascade (
+			k INT PRIMARY KEY, p INT REFERENCES t.parent (p) ON DELETE CASCADE
+		);
+		CREATE TABLE t.update_set_null (
+			k INT PRIMARY KEY, p INT REFERENCES t.parent (p) ON UPDATE SET NULL
+		);
+		CREATE TABLE t.delete_set_default (
+			k INT PRIMARY KEY, p INT DEFAULT 0 REFERENCES t.parent (p) ON DELETE SET DEFAULT
+		);
+		CREATE TABLE t.leaf (k INT PRIMARY KEY);
+	`)
+	defer cleanup()
+
+	originNames := func(fks []cat.ForeignKeyConstraint) []string {
+		var res []string
+		for _, fk := range fks {
+			ds, _, err := srv.oc.ResolveDataSourceByID(
+				ctx, cat.Flags{NoTableStats: true}, fk.OriginTableID(),
+			)
+			require.NoError(t, err)
+			res = append(res, string(ds.Name()))
+		}
+		return res
+	}
+
+	parent := srv.resolve(t, "parent").(*optTable)
+	require.Equal(t, 5, parent.InboundForeignKeyCount())
+	require.ElementsMatch(t,
+		[]string{"delete_cascade", "update_set_null", "delete_set_default"},
+		originNames(parent.CascadingInboundForeignKeys()),
+	)
+
+	require.Empty(t, srv.resolve(t, "leaf").(*optTable).CascadingInboundForeignKeys())
+}
+
+func TestOptTableForeignKeyColumns(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	srv, cleanup := startOptCatalogTestServer(t, `
+		CREATE DATABASE t;
+		CREATE TABLE t.parent (a INT PRIMARY KEY, b INT, c INT, d INT, UNIQUE (b, c));
+		CREATE TABLE t.child (
+			k INT PRIMARY KEY,
+			x INT REFERENCES t.parent (a),
+			y INT,
+			z INT,
+			w INT,
+			FOREIGN KEY (y, z) REFERENCES t.parent (b, c)
+		);
+		CREATE TABLE t.tree (id INT PRIMARY KEY, parent_id INT REFERENCES t.tree (id), v INT);
+		CREATE TABLE t.plain (k INT PRIMARY KEY, v INT);
+	`)
+	defer cleanup()
+
+	testCases := []struct {
+		table    string
+		expected util.FastIntSet
+	}{
+		// Inbound references to a, and to b and c.
+		{table: "parent", expected: util.MakeFastIntSet(0, 1, 2)},
+		// Outbound references from x, and from y and z.
+		{table: "child", expected: util.MakeFastIntSet(1, 2, 3)},
+		// The self-reference contributes both its origin and referenced columns.
+		{table: "tree", expected: util.MakeFastIntSet(0, 1)},
+		{table: "plain", expected: util.FastIntSet{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.table, func(t *testing.T) {
+			ds := srv.resolve(t, tree.Name(tc.table))
+			cols := ds.(*optTable).ForeignKeyColumns()
+			require.True(t, tc.expected.Equals(cols), "expected %s, got %s", tc.expected, cols)
+		})
+	}
+}
+
+func TestOptVirtualTableIsUnimplemented(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	srv, cleanup := startOptCatalogTestServer(t, "" /* setup */)
+	defer cleanup()
+
+	testCases := []struct {
+		schema, table string
+		expected      bool
+	}{
+		{schema: "pg_catalog", table: "pg_class", expected: false},
+		{schema: "information_schema", table: "tables", expected: false},
+		// Empty because the feature does not exist, but not stubs.
+		{schema: "pg_catalog", table: "pg_event_trigger", expected: false},
+		{schema: "pg_catalog", table: "pg_conversion", expected: false},
+		{schema: "pg_catalog", table: "pg_default_acl", expected: false},
+		{schema: "information_schema", table: "routines", expected: false},
+		{schema: "information_schema", table: "parameters", expected: false},
+		// Stubs which have not been populated yet.
+		{schema: "pg_catalog", table: "pg_cast", expected: true},
+		{schema: "pg_catalog", table: "pg_shdepend", expected: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.schema+"."+tc.table, func(t *testing.T) {
+			tn := tree.MakeTableNameWithSchema("system", tree.Name(tc.schema), tree.Name(tc.table))
+			ds, _, err := srv.oc.ResolveDataSource(ctx, cat.Flags{}, &tn)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, ds.(*optVirtualTable).IsUnimplemented())
+		})
+	}
+}
+
+func TestOptTableFingerprint(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// makeDesc returns a new descriptor whose e column has a user-defined type
+	// with the given version.
+	makeDesc := func(typeVersion uint32) *tabledesc.Mutable {
+		desc := makeTestTableDesc(t, `
+			CREATE TABLE t (k INT PRIMARY KEY, v INT, e INT, INDEX v_idx (v))
+		`)
+		typ := types.MakeEnum(typedesc.TypeIDToOID(500), typedesc.TypeIDToOID(501))
+		typ.TypeMeta.Version = typeVersion
+		desc.Columns[2].Type = typ
+		return desc
+	}
+	makeStat := func(createdAt int64, colIDs ...descpb.ColumnID) *stats.TableStatistic {
+		return &stats.TableStatistic{TableStatisticProto: stats.TableStatisticProto{
+			ColumnIDs: colIDs,
+			CreatedAt: timeutil.Unix(createdAt, 0),
+			RowCount:  100,
+		}}
+	}
+	makeStats := func() []*stats.TableStatistic {
+		return []*stats.TableStatistic{makeStat(2, 1), makeStat(1, 2)}
+	}
+	// makeZone returns a new table zone. If indexSubzones is true, both indexes
+	// have a subzone of their own. If partitionReplicas is non-zero, a subzone
+	// with that many replicas is added for a partition of the primary index.
+	makeZone := func(numReplicas int32, indexSubzones bool, partitionReplicas int32) *zonepb.ZoneConfig {
+		zone := zonepb.DefaultZoneConfig()
+		zone.NumReplicas = proto.Int32(numReplicas)
+		if indexSubzones {
+			for _, indexID := range []uint32{1, 2} {
+				zone.Subzones = append(zone.Subzones, zonepb.Subzone{
+					IndexID: indexID,
+					Config:  zonepb.ZoneConfig{NumReplicas: proto.Int32(5)},
+				})
+			}
+		}
+		if partitionReplicas != 0 {
+			zone.Subzones = append(zone.Subzones, zonepb.Subzone{
+				IndexID:       1,
+				PartitionName: "p",
+				Config:        zonepb.ZoneConfig{NumReplicas: proto.Int32(partitionReplicas)},
+			})
+		}
+		return &zone
+	}
+	makeTable := func(
+		desc *tabledesc.Mutable, tableStats []*stats.TableStatistic, zone *zonepb.ZoneConfig,
+	) *optTable {
+		ot, err := newOptTable(
+			tabledesc.NewImmutable(*desc.TableDesc()),
+			keys.SystemSQLCodec,
+			&cluster.MakeTestingClusterSettings().SV,
+			tableStats,
+			zone,
+		)
+		require.NoError(t, err)
+		return ot
+	}
+	orig := makeTable(makeDesc(1), makeStats(), makeZone(3, false, 0))
+	withIndexSubzones := makeTable(makeDesc(1), makeStats(), makeZone(3, true, 0))
+	newVersion := makeDesc(1)
+	newVersion.Version++
+	otherCreatedAt := []*stats.TableStatistic{makeStat(3, 1), makeStat(1, 2)}
+	otherColumns := []*stats.TableStatistic{makeStat(2, 2), makeStat(1, 2)}
+
+	testCases := []struct {
+		name        string
+		left, right *optTable
+		equal       bool
+	}{
+		{
+			// Equivalent, but separately allocated, inputs.
+			name:  "equivalent",
+			left:  orig,
+			right: makeTable(makeDesc(1), makeStats(), makeZone(3, false, 0)),
+			equal: true,
+		},
+		{
+			name:  "version",
+			left:  orig,
+			right: makeTable(newVersion, makeStats(), makeZone(3, false, 0)),
+		},
+		{
+			name:  "no stats",
+			left:  orig,
+			right: makeTable(makeDesc(1), nil, makeZone(3, false, 0)),
+		},
+		{
+			name:  "stats created at",
+			left:  orig,
+			right: makeTable(makeDesc(1), otherCreatedAt, makeZone(3, false, 0)),
+		},
+		{
+			name:  "stats columns",
+			left:  orig,
+			right: makeTable(makeDesc(1), otherColumns, makeZone(3, false, 0)),
+		},
+		{
+			name:  "type version",
+			left:  orig,
+			right: makeTable(makeDesc(2), makeStats(), makeZone(3, false, 0)),
+		},
+		{
+			name:  "table zone",
+			left:  orig,
+			right: makeTable(makeDesc(1), makeStats(), makeZone(5, false, 0)),
+		},
+		{
+			name:  "index subzones",
+			left:  orig,
+			right: withIndexSubzones,
+		},
+		{
+			// Indexes without a subzone of their own use the whole table zone,
+			// including the subzones of partitions.
+			name:  "partition subzone",
+			left:  orig,
+			right: makeTable(makeDesc(1), makeStats(), makeZone(3, false, 7)),
+		},
+		{
+			// When every index has its own subzone, the subzones of partitions
+			// don't affect the zone of any index.
+			name:  "partition subzone with index subzones",
+			left:  withIndexSubzones,
+			right: makeTable(makeDesc(1), makeStats(), makeZone(3, true, 7)),
+			equal: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.equal, tc.left.Equals(tc.right))
+			require.Equal(t, tc.equal, tc.right.Equals(tc.left))
+			leftFP, err := tc.left.Fingerprint()
+			require.NoError(t, err)
+			rightFP, err := tc.right.Fingerprint()
+			require.NoError(t, err)
+			require.Equal(t, tc.equal, leftFP == rightFP)
+		})
+	}
+}
+
+func TestOptTablePrimaryStoredColumns(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ot := makeTestOptTable(t, makeTestTableDesc(t, `
+		CREATE TABLE t (
+			a INT,
+			b INT,
+			c STRING,
+			d INT,
+			PRIMARY KEY (d, a),
+			INDEX (b) STORING (c)
+		)`,
+	))
+
+	// The primary index also includes the system columns.
+	var names []tree.Name
+	for _, col := range ot.PrimaryStoredColumns() {
+		names = append(names, col.ColName())
+	}
+	require.Equal(t, []tree.Name{"b", "c", colinfo.MVCCTimestampColumnName}, names)
+}
+
+func TestOptIndexReversedColumn(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ot := makeTestOptTable(t, makeTestTableDesc(t, `
+		CREATE TABLE t (
+			a INT,
+			b INT,
+			c INT,
+			d INT,
+			PRIMARY KEY (a DESC, b),
+			INDEX mixed_idx (c, d DESC)
+		)
+	`))
+
+	testCases := []struct {
+		index    cat.IndexOrdinal
+		reversed []bool
+	}{
+		// The primary index key is (a DESC, b).
+		{index: cat.PrimaryIndex, reversed: []bool{false, true}},
+		// The secondary index key is (c, d DESC), followed by the ascending
+		// primary key suffix (a, b).
+		{index: 1, reversed: []bool{true, false, true, true}},
+	}
+	for _, tc := range testCases {
+		idx := ot.Index(tc.index).(*optIndex)
+		t.Run(string(idx.Name()), func(t *testing.T) {
+			var reversed []bool
+			for i := 0; i < idx.KeyColumnCount(); i++ {
+				require.Equal(t, idx.Column(i).Column, idx.ReversedColumn(i).Column, "column %d", i)
+				reversed = append(reversed, idx.ReversedColumn(i).Descending)
+			}
+			require.Equal(t, tc.reversed, reversed)
+		})
+	}
+}
+
+func TestOptCatalogSameDatabase(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	srv, cleanup := startOptCatalogTestServer(t, `
+		CREATE DATABASE db1;
+		CREATE DATABASE db2;
+		CREATE SCHEMA db1.sc;
+		CREATE TABLE db1.x (k INT PRIMARY KEY);
+		CREATE TABLE db1.sc.y (k INT PRIMARY KEY);
+		CREATE TABLE db2.x (k INT PRIMARY KEY);
+		CREATE VIEW db2.v AS SELECT k FROM db2.x;
+	`)
+	defer cleanup()
+
+	resolve := func(db, schema, name string) cat.DataSource {
+		tn := tree.MakeTableNameWithSchema(tree.Name(db), tree.Name(schema), tree.Name(name))
+		ds, _, err := srv.oc.ResolveDataSource(ctx, cat.Flags{NoTableStats: true}, &tn)
+		require.NoError(t, err)
+		return ds
+	}
+	db1X := resolve("db1", "public", "x")
+	db1Y := resolve("db1", "sc", "y")
+	db2X := resolve("db2", "public", "x")
+	db2V := resolve("db2", "public", "v")
+	db1Tables := resolve("db1", "information_schema", "tables")
+	db2Tables := resolve("db2", "information_schema", "tables")
+	allTables := resolve("", "information_schema", "tables")
+
+	testCases := []struct {
+		name     string
+		a, b     cat.DataSource
+		expected bool
+	}{
+		{name: "same table", a: db1X, b: db1X, expected: true},
+		{name: "same database", a: db1X, b: db1Y, expected: true},
+		{name: "different databases", a: db1X, b: db2X, expected: false},
+		{name: "table and view", a: db2X, b: db2V, expected: true},
+		{name: "virtual table in same database", a: db1X, b: db1Tables, expected: true},
+		{name: "virtual table in other database", a: db2X, b: db1Tables, expected: false},
+		{name: "virtual tables", a: db2Tables, b: db2V, expected: true},
+		{name: "unscoped virtual table", a: allTables, b: db1X, expected: false},
+		{name: "unscoped virtual tables", a: allTables, b: allTables, expected: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			same, err := srv.oc.SameDatabase(tc.a, tc.b)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, same)
+			same, err = srv.oc.SameDatabase(tc.b, tc.a)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, same)
+		})
+	}
+}
+
+func TestOptCatalogValidateForeignKey(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	srv, cleanup := startOptCatalogTestServer(t, `
+		CREATE DATABASE t;
+		CREATE TABLE t.parent (a INT, b INT, PRIMARY KEY (a, b));
+		CREATE TABLE t.child (
+			c INT PRIMARY KEY,
+			x INT,
+			y INT,
+			CONSTRAINT fk FOREIGN KEY (x, y) REFERENCES t.parent (a, b) ON DELETE CASCADE
+		);
+	`)
+	defer cleanup()
+
+	// Resolve the tables in the same way ValidateForeignKey does, so that the
+	// cached table objects are shared.
+	resolve := func(name string) *optTable {
+		desc := catalogkv.TestingGetTableDescriptor(srv.kvDB, keys.SystemSQLCodec, "t", name)
+		ds, _, err := srv.oc.ResolveDataSourceByID(
+			ctx, cat.Flags{NoTableStats: true}, cat.StableID(desc.GetID()),
+		)
+		require.NoError(t, err)
+		return ds.(*optTable)
+	}
+	parent, child := resolve("parent"), resolve("child")
+	require.Equal(t, 1, parent.InboundForeignKeyCount())
+	require.Equal(t, 1, child.OutboundForeignKeyCount())
+
+	t.Run("consistent", func(t *testing.T) {
+		require.NoError(t, srv.oc.ValidateForeignKey(ctx, child.OutboundForeignKey(0)))
+		require.NoError(t, srv.oc.ValidateForeignKey(ctx, parent.InboundForeignKey(0)))
+	})
+
+	t.Run("mismatched", func(t *testing.T) {
+		// Corrupt the inbound copy of the constraint in the cached table, which is
+		// returned again when ValidateForeignKey resolves the referenced table.
+		inbound := &parent.inboundFKs[0]
+		orig := *inbound
+		defer func() { *inbound = orig }()
+
+		inbound.deleteAction = descpb.ForeignKeyReference_NO_ACTION
+		err := srv.oc.ValidateForeignKey(ctx, child.OutboundForeignKey(0))
+		require.Error(t, err)
+		require.Regexp(t, `inbound and outbound foreign key "fk" differ: delete action`, err)
+		*inbound = orig
+
+		inbound.referencedColumns = []descpb.ColumnID{orig.referencedColumns[1], orig.referencedColumns[0]}
+		err = srv.oc.ValidateForeignKey(ctx, child.OutboundForeignKey(0))
+		require.Error(t, err)
+		require.Regexp(t, `referenced column 0`, err)
+		*inbound = orig
+
+		inbound.name = "other"
+		err = srv.oc.ValidateForeignKey(ctx, child.OutboundForeignKey(0))
+		require.Error(t, err)
+		require.Regexp(t, `foreign key "fk" is missing from referenced table "parent"`, err)
+	})
+
+	t.Run("mismatched argument", func(t *testing.T) {
+		fk := *child.OutboundForeignKey(0).(*optForeignKeyConstraint)
+		fk.validity = descpb.ConstraintValidity_Unvalidated
+		err := srv.oc.ValidateForeignKey(ctx, &fk)
+		require.Error(t, err)
+		require.Regexp(t, `foreign key "fk" differs from the catalog: validated`, err)
+	})
+}
+
+func TestOptTablePartitionZone(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	desc := makeTestTableDesc(t, `CREATE TABLE t (k INT PRIMARY KEY, v INT, INDEX v_idx (v))`)
+	secondaryID := uint32(desc.TableDesc().Indexes[0].ID)
+
+	tblZone := zonepb.DefaultZoneConfig()
+	tblZone.Subzones = []zonepb.Subzone{
+		// A zone for the entire secondary index.
+		{
+			IndexID: secondaryID,
+			Config:  zonepb.ZoneConfig{NumReplicas: proto.Int32(5)},
+		},
+		// Zones for partitions of the primary and secondary indexes.
+		{
+			IndexID:       uint32(desc.TableDesc().PrimaryIndex.ID),
+			PartitionName: "p1",
+			Config:        zonepb.ZoneConfig{NumReplicas: proto.Int32(7)},
+		},
+		{
+			IndexID:       secondaryID,
+			PartitionName: "p2",
+			Config: zonepb.ZoneConfig{
+				LeasePreferences: []zonepb.LeasePreference{
+					{Constraints: []zonepb.Constraint{{Type: zonepb.Constraint_REQUIRED, Value: "us-east1"}}},
+				},
+			},
+		},
+	}
+	ot, err := newOptTable(
+		tabledesc.NewImmutable(*desc.TableDesc()),
+		keys.SystemSQLCodec,
+		&cluster.MakeTestingClusterSettings().SV,
+		nil, /* stats */
+		&tblZone,
+	)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		index      int
+		partition  string
+		found      bool
+		replicas   int32
+		leasePrefs int
+	}{
+		{index: 0, partition: "p1", found: true, replicas: 7},
+		// Inherits the number of replicas from the index zone.
+		{index: 1, partition: "p2", found: true, replicas: 5, leasePrefs: 1},
+		// Partitions without a zone of their own.
+		{index: 0, partition: "p2", found: false},
+		{index: 1, partition: "p1", found: false},
+		{index: 1, partition: "", found: false},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("%d/%s", tc.index, tc.partition), func(t *testing.T) {
+			zone, found := ot.PartitionZone(tc.index, tc.partition)
+			require.Equal(t, tc.found, found)
+			if !found {
+				require.Nil(t, zone)
+				return
+			}
+			require.Equal(t, tc.replicas, *zone.(*zonepb.ZoneConfig).NumReplicas)
+			require.Equal(t, tc.leasePrefs, zone.LeasePreferenceCount())
+		})
+	}
+}
+
+func TestOptCatalogPrivileges(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	srv, cleanup := startOptCatalogTestServer(t, `
+		CREATE DATABASE t;
+		CREATE TABLE t.x (k INT PRIMARY KEY);
+		CREATE USER u1;
+		CREATE USER u2;
+		GRANT SELECT ON t.x TO u1;
+		GRANT INSERT, UPDATE ON t.x TO u2;
+	`)
+	defer cleanup()
+
+	ds := srv.resolve(t, "x")
+
+	privs, err := srv.oc.Privileges(ds)
+	require.NoError(t, err)
+	require.Equal(t, security.RootUserName(), privs.Owner())
+
+	u1, u2 := security.MakeSQLUsernameFromPreNormalizedString("u1"),
+		security.MakeSQLUsernameFromPreNormalizedString("u2")
+	grants := make(map[security.SQLUsername][]string)
+	for _, u := range privs.Show(privilege.Table) {
+		grants[u.User] = u.Privileges
+	}
+	require.Equal(t, []string{"SELECT"}, grants[u1])
+	require.Equal(t, []string{"INSERT", "UPDATE"}, grants[u2])
+	require.Contains(t, grants, security.AdminRoleName())
+	require.Contains(t, grants, security.RootUserName())
+
+	// The returned descriptor is a copy.
+	privs.Grant(u1, privilege.List{privilege.DELETE})
+	require.True(t, privs.CheckPrivilege(u1, privilege.DELETE))
+	privs, err = srv.oc.Privileges(ds)
+	require.NoError(t, err)
+	require.False(t, privs.CheckPrivilege(u1, privilege.DELETE))
+}
+
+func TestOptTableColumnTypeModifier(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ot := makeTestOptTable(t, makeTestTableDesc(t, `
+		CREATE TABLE t (
+			i INT PRIMARY KEY,
+			s VARCHAR(10),
+			d DECIMAL(10, 2),
+			u STRING,
+			a VARCHAR(5)[]
+		)
+	`))
+
+	testCases := []struct {
+		ord      int
+		expected int32
+	}{
+		{ord: 0, expected: -1},
+		// The width plus the 4-byte header.
+		{ord: 1, expected: 14},
+		// The precision in the upper 16 bits and the scale in the lower 16 bits,
+		// plus the 4-byte header.
+		{ord: 2, expected: (10<<16 | 2) + 4},
+		{ord: 3, expected: -1},
+		// Arrays report the modifier of their element type.
+		{ord: 4, expected: 9},
+	}
+	for _, tc := range testCases {
+		col := ot.Column(tc.ord)
+		t.Run(string(col.ColName()), func(t *testing.T) {
+			require.Equal(t, tc.expected, col.TypeModifier())
+		})
+	}
+}
+
+func TestOptCatalogResolveTypeOID(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	srv, cleanup := startOptCatalogTestServer(t, `
+		CREATE DATABASE t;
+		CREATE TYPE t.public.greeting AS ENUM ('hello', 'hi');
+	`)
+	defer cleanup()
+
+	var enumOID oid.Oid
+	srv.r.QueryRow(t, `SELECT 't.public.greeting'::REGTYPE::OID`).Scan(&enumOID)
+
+	testCases := []struct {
+		parts    []string
+		expected oid.Oid
+	}{
+		{parts: []string{"int4"}, expected: oid.T_int4},
+		{parts: []string{"pg_catalog", "text"}, expected: oid.T_text},
+		{parts: []string{"t", "public", "greeting"}, expected: enumOID},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprint(tc.parts), func(t *testing.T) {
+			var parts [3]string
+			for i := range tc.parts {
+				parts[i] = tc.parts[len(tc.parts)-1-i]
+			}
+			name, err := tree.NewUnresolvedObjectName(len(tc.parts), parts, 0 /* annotationIdx */)
+			require.NoError(t, err)
+			typOID, err := srv.oc.ResolveTypeOID(ctx, name)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, typOID)
+
+			typ, err := srv.oc.ResolveTypeByOID(ctx, typOID)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, typ.Oid())
+		})
+	}
+
+	t.Run("missing", func(t *testing.T) {
+		name, err := tree.NewUnresolvedObjectName(3, [3]string{"missing", "public", "t"}, 0 /* annotationIdx */)
+		require.NoError(t, err)
+		_, err = srv.oc.ResolveTypeOID(ctx, name)
+		require.Error(t, err)
+		require.Equal(t, pgcode.UndefinedObject, pgerror.GetPGCode(err))
+	})
+}
+
+func TestOptCatalogDescribe(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	srv, cleanup := startOptCatalogTestServer(t, `
+		CREATE DATABASE t;
+		CREATE TABLE t.parent (p INT PRIMARY KEY);
+		CREATE TABLE t.child (
+			a INT,
+			b STRING NOT NULL DEFAULT 'x',
+			c INT CHECK (c > 0),
+			UNIQUE INDEX b_idx (b) STORING (c),
+			CONSTRAINT fk FOREIGN KEY (a) REFERENCES t.parent (p)
+		);
+		CREATE VIEW t.v AS SELECT p FROM t.parent;
+	`)
+	defer cleanup()
+
+	desc, err := srv.oc.Describe(ctx, srv.resolve(t, "child"))
+	require.NoError(t, err)
+	require.Equal(t, tree.Name("child"), desc.Name)
+
+	// System columns are not described.
+	testCases := []struct {
+		name     tree.Name
+		nullable bool
+		hidden   bool
+		def      string
+	}{
+		{name: "a", nullable: true},
+		{name: "b", def: "'x':::STRING"},
+		{name: "c", nullable: true},
+		{name: "rowid", hidden: true, def: "unique_rowid()"},
+	}
+	require.Len(t, desc.Columns, len(testCases))
+	for i, tc := range testCases {
+		col := desc.Columns[i]
+		require.Equal(t, tc.name, col.Name)
+		require.Equal(t, tc.nullable, col.Nullable, "column %s", tc.name)
+		require.Equal(t, tc.hidden, col.Hidden, "column %s", tc.name)
+		require.Equal(t, tc.def, col.DefaultExpr, "column %s", tc.name)
+	}
+
+	// The key of a unique index on a NOT NULL column doesn't include the primary
+	// key, which is stored in the index instead.
+	require.Len(t, desc.Indexes, 2)
+	idx := desc.Indexes[1]
+	require.Equal(t, tree.Name("b_idx"), idx.Name)
+	require.True(t, idx.Unique)
+	var keyCols, storedCols []tree.Name
+	for _, col := range idx.KeyColumns {
+		keyCols = append(keyCols, col.ColName())
+	}
+	for _, col := range idx.StoredColumns {
+		storedCols = append(storedCols, col.ColName())
+	}
+	require.Equal(t, []tree.Name{"b"}, keyCols)
+	require.Equal(t, []tree.Name{"rowid", "c"}, storedCols)
+
+	require.Len(t, desc.OutboundForeignKeys, 1)
+	require.Equal(t, "fk", desc.OutboundForeignKeys[0].Name())
+	require.Empty(t, desc.InboundForeignKeys)
+	require.Len(t, desc.Checks, 1)
+
+	parentDesc, err := srv.oc.Describe(ctx, srv.resolve(t, "parent"))
+	require.NoError(t, err)
+	require.Len(t, parentDesc.InboundForeignKeys, 1)
+
+	_, err = srv.oc.Describe(ctx, srv.resolve(t, "v"))
+	require.Error(t, err)
+	require.Equal(t, pgcode.WrongObjectType, pgerror.GetPGCode(err))
+}
+
+func TestOptIndexIsPrimaryIndex(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// Only the dummy primary index of a virtual table, which precedes the
+	// indexes of its descriptor, is a primary index.
+	desc := makeTestTableDesc(t, `CREATE TABLE t (a INT, b INT, INDEX (a), INDEX (b))`)
+	tn := tree.MakeTableNameWithSchema("", "crdb_internal", "t")
+	vt, err := newOptVirtualTable(
+		context.Background(), nil /* oc */, tabledesc.NewImmutable(*desc.TableDesc()), &tn,
+	)
+	require.NoError(t, err)
+	for i := 0; i < vt.IndexCount(); i++ {
+		require.Equal(t, i == cat.PrimaryIndex, vt.Index(i).IsPrimaryIndex(), "index %d", i)
+	}
+}
+
+func TestOptIndexKeyColumnSets(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ot := makeTestOptTable(t, makeTestTableDesc(t, `
+		CREATE TABLE t (
+			a INT,
+			b INT,
+			c INT,
+			d INT,
+			PRIMARY KEY (b, a),
+			INDEX c_idx (c) STORING (d),
+			UNIQUE INDEX d_idx (d)
+		)
+	`))
+
+	testCases := []struct {
+		index  cat.IndexOrdinal
+		key    []int
+		laxKey []int
+	}{
+		{index: cat.PrimaryIndex, key: []int{0, 1}, laxKey: []int{0, 1}},
+		// Non-unique index: the key includes the primary key suffix.
+		{index: 1, key: []int{0, 1, 2}, laxKey: []int{0, 1, 2}},
+		// Unique index on a nullable column: the lax key is just (d), while the key
+		// includes the primary key suffix.
+		{index: 2, key: []int{0, 1, 3}, laxKey: []int{3}},
+	}
+	for _, tc := range testCases {
+		idx := ot.Index(tc.index).(*optIndex)
+		t.Run(string(idx.Name()), func(t *testing.T) {
+			key, laxKey := idx.KeyColumnSet(), idx.LaxKeyColumnSet()
+			require.True(t, util.MakeFastIntSet(tc.key...).Equals(key), "key %s", key)
+			require.True(t, util.MakeFastIntSet(tc.laxKey...).Equals(laxKey), "lax key %s", laxKey)
+		})
+	}
+}
+
+func TestOptCatalogReferencingTables(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	srv, cleanup := startOptCatalogTestServer(t, `
+		CREATE DATABASE t;
+		CREATE TABLE t.parent (p INT PRIMARY KEY, q INT UNIQUE);
+		CREATE TABLE t.child1 (k INT PRIMARY KEY, p INT REFERENCES t.parent (p));
+		CREATE TABLE t.child2 (
+			k INT PRIMARY KEY,
+			p INT REFERENCES t.parent (p),
+			q INT REFERENCES t.parent (q)
+		);
+		CREATE TABLE t.leaf (k INT PRIMARY KEY);
+	`)
+	defer cleanup()
+
+	tableID := func(name string) cat.StableID {
+		desc := catalogkv.TestingGetTableDescriptor(srv.kvDB, keys.SystemSQLCodec, "t", name)
+		return cat.StableID(desc.GetID())
+	}
+
+	names := func(tabs []cat.Table) []string {
+		var res []string
+		for _, tab := range tabs {
+			res = append(res, string(tab.Name()))
+		}
+		return res
+	}
+
+	// child2 references parent twice, but is only returned once.
+	tabs, err := srv.oc.ReferencingTables(ctx, tableID("parent"))
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"child1", "child2"}, names(tabs))
+
+	tabs, err = srv.oc.ReferencingTables(ctx, tableID("leaf"))
+	require.NoError(t, err)
+	require.Empty(t, tabs)
+
+	_, err = srv.oc.ReferencingTables(ctx, 12345)
+	require.Error(t, err)
+	require.Equal(t, pgcode.UndefinedTable, pgerror.GetPGCode(err))
+}
+
+func TestOptCatalogGetSchemaNames(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	srv, cleanup := startOptCatalogTestServer(t, `
+		CREATE DATABASE t;
+		CREATE SCHEMA t.sc2;
+		CREATE SCHEMA t.sc1;
+		CREATE DATABASE d2;
+	`)
+	defer cleanup()
+
+	virtualSchemas := []string{"crdb_internal", "information_schema", "pg_catalog", "pg_extension"}
+	getNames := func(t *testing.T, db string) []string {
+		schemas, err := srv.oc.GetSchemaNames(ctx, db)
+		require.NoError(t, err)
+		var res []string
+		for _, sc := range schemas {
+			require.Equal(t, tree.Name(db), sc.CatalogName)
+			res = append(res, string(sc.SchemaName))
+		}
+		return res
+	}
+
+	t.Run("user-defined schemas", func(t *testing.T) {
+		names := getNames(t, "t")
+		require.Equal(t, []string{"public", "sc1", "sc2"}, names[:3])
+		require.ElementsMatch(t, virtualSchemas, names[3:])
+	})
+
+	t.Run("default schemas", func(t *testing.T) {
+		names := getNames(t, "d2")
+		require.Equal(t, []string{"public"}, names[:1])
+		require.ElementsMatch(t, virtualSchemas, names[1:])
+	})
+
+	t.Run("missing database", func(t *testing.T) {
+		_, err := srv.oc.GetSchemaNames(ctx, "missing")
+		require.Error(t, err)
+		require.Equal(t, pgcode.UndefinedDatabase, pgerror.GetPGCode(err))
+	})
+}
+
+func TestOptTableColumnTypeOID(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	srv, cleanup := startOptCatalogTestServer(t, `
+		CREATE DATABASE t;
+		CREATE TYPE t.greeting AS ENUM ('hello', 'hi');
+		CREATE TABLE t.tab (k INT PRIMARY KEY, s STRING, g t.greeting);
+	`)
+	defer cleanup()
+
+	var enumOID oid.Oid
+	srv.r.QueryRow(t, `SELECT 't.public.greeting'::REGTYPE::OID`).Scan(&enumOID)
+
+	tab := srv.resolve(t, "tab").(cat.Table)
+	testCases := []struct {
+		ord      int
+		expected oid.Oid
+	}{
+		{ord: 0 /* k */, expected: oid.T_int8},
+		{ord: 1 /* s */, expected: oid.T_text},
+		// User-defined types have an OID derived from their descriptor ID.
+		{ord: 2 /* g */, expected: enumOID},
+	}
+	for _, tc := range testCases {
+		col := tab.Column(tc.ord)
+		require.Equal(t, tc.expected, col.TypeOID(), "column %s", col.ColName())
+	}
+}
+
+func TestOptTableHasUserCheckConstraints(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	srv, cleanup := startOptCatalogTestServer(t, `
+		CREATE DATABASE t;
+		CREATE TYPE t.greeting AS ENUM ('hello', 'hi');
+		CREATE TABLE t.none (k INT PRIMARY KEY);
+		CREATE TABLE t.synthesized (k INT PRIMARY KEY, g t.greeting);
+		CREATE TABLE t.declared (k INT PRIMARY KEY CHECK (k > 0), g t.greeting);
+	`)
+	defer cleanup()
+
+	testCases := []struct {
+		table    string
+		checks   int
+		expected bool
+	}{
+		{table: "none", checks: 0, expected: false},
+		{table: "synthesized", checks: 1, expected: false},
+		{table: "declared", checks: 2, expected: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.table, func(t *testing.T) {
+			tab := srv.resolve(t, tree.Name(tc.table)).(*optTable)
+			require.Equal(t, tc.checks, tab.CheckCount())
+			require.Equal(t, tc.expected, tab.HasUserCheckConstraints())
+		})
+	}
+}
+
+func TestOptCatalogResolveDataSourceAsOwner(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	s, sqlDB, kvDB := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(ctx)
+
+	r := sqlutils.MakeSQLRunner(sqlDB)
+	r.Exec(t, `
+		CREATE DATABASE t;
+		CREATE USER testuser;
+		CREATE USER definer;
+		CREATE TABLE t.public.tab (k INT PRIMARY KEY);
+		CREATE SCHEMA t.sc;
+		CREATE TABLE t.sc.tab (k INT PRIMARY KEY);
+		GRANT USAGE ON SCHEMA t.sc TO definer;
+		GRANT SELECT ON TABLE t.public.tab, t.sc.tab TO definer;
+	`)
+	testUser := security.MakeSQLUsernameFromPreNormalizedString("testuser")
+	definer := security.MakeSQLUsernameFromPreNormalizedString("definer")
+
+	oc, cleanup := makeTestOptCatalogForUser(ctx, s, kvDB, testUser)
+	defer cleanup()
+
+	for _, schema := range []string{"public", "sc"} {
+		t.Run(schema, func(t *testing.T) {
+			tn := tree.MakeTableNameWithSchema("t", tree.Name(schema), "tab")
+
+			// The session user can't read the table directly.
+			ds, _, err := oc.ResolveDataSource(ctx, cat.Flags{NoTableStats: true}, &tn)
+			if err == nil {
+				err = oc.CheckPrivilege(ctx, ds, privilege.SELECT)
+			}
+			require.Error(t, err)
+			require.Equal(t, pgcode.InsufficientPrivilege, pgerror.GetPGCode(err))
+
+			// But it can through a definer that has access.
+			ds, resName, err := oc.ResolveDataSourceAsOwner(
+				ctx, cat.Flags{NoTableStats: true}, &tn, definer,
+			)
+			require.NoError(t, err)
+			require.Equal(t, tree.Name(schema), resName.SchemaName)
+			require.Equal(t, tree.Name("tab"), ds.Name())
+
+			// Resolving as an owner without access fails.
+			_, _, err = oc.ResolveDataSourceAsOwner(
+				ctx, cat.Flags{NoTableStats: true}, &tn, testUser,
+			)
+			require.Error(t, err)
+			require.Equal(t, pgcode.InsufficientPrivilege, pgerror.GetPGCode(err))
+		})
+	}
+}
+
+func TestOptTableStatsAreStale(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	desc := makeTestTableDesc(t, `CREATE TABLE t (a INT PRIMARY KEY, b INT)`)
+	st := cluster.MakeTestingClusterSettings()
+	makeTable := func(tableStats ...*stats.TableStatistic) *optTable {
+		ot, err := newOptTable(
+			tabledesc.NewImmutable(*desc.TableDesc()),
+			keys.SystemSQLCodec,
+			&st.SV,
+			tableStats,
+			emptyZoneConfig,
+		)
+		require.NoError(t, err)
+		return ot
+	}
+	makeStat := func(rowCount uint64, createdAt int64) *stats.TableStatistic {
+		return &stats.TableStatistic{TableStatisticProto: stats.TableStatisticProto{
+			ColumnIDs: []descpb.ColumnID{1},
+			CreatedAt: timeutil.Unix(createdAt, 0),
+			RowCount:  rowCount,
+		}}
+	}
+
+	// A table without statistics always has stale stats.
+	require.True(t, makeTable().StatsAreStale(0))
+
+	// With the default settings, the threshold is 20% of the rows of the most
+	// recent statistic plus 500 rows.
+	ot := makeTable(makeStat(10000, 2), makeStat(100, 1))
+	testCases := []struct {
+		delta    float64
+		expected bool
+	}{
+		{delta: 0, expected: false},
+		{delta: 100, expected: false},
+		{delta: 2000, expected: false},
+		{delta: 2499, expected: false},
+		{delta: 2500, expected: true},
+		{delta: 100000, expected: true},
+	}
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, ot.StatsAreStale(tc.delta), "delta %v", tc.delta)
+	}
+
+	// The threshold follows the automatic statistics cluster settings.
+	stats.AutomaticStatisticsFractionStaleRows.Override(&st.SV, 0.1)
+	stats.AutomaticStatisticsMinStaleRows.Override(&st.SV, 0)
+	require.False(t, ot.StatsAreStale(999))
+	require.True(t, ot.StatsAreStale(1000))
+}
+
+func TestOptIndexJSONFetchExpr(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ot := makeTestOptTable(t, makeTestTableDesc(t, `
+		CREATE TABLE t (
+			k INT PRIMARY KEY,
+			data JSONB,
+			id STRING AS (data->>'id') STORED,
+			path STRING AS (data#>>'{a,b}') STORED,
+			c INT AS (k + 1) STORED,
+			INDEX id_idx (id),
+			INDEX path_idx (path, k),
+			INDEX c_idx (c),
+			INDEX k_id_idx (k, id),
+			INVERTED INDEX data_idx (data)
+		)
+	`))
+
+	expected := map[tree.Name]tree.BinaryOperator{
+		"id_idx":   tree.JSONFetchText,
+		"path_idx": tree.JSONFetchTextPath,
+	}
+	for i := 0; i < ot.IndexCount(); i++ {
+		idx := ot.Index(i).(*optIndex)
+		expr, ok := idx.JSONFetchExpr()
+		if op, isJSON := expected[idx.Name()]; isJSON {
+			require.True(t, ok, "index %s", idx.Name())
+			require.Equal(t, op, expr.Operator)
+			require.Equal(t, "data", tree.AsString(expr.Left))
+		} else {
+			require.False(t, ok, "index %s", idx.Name())
+			require.Nil(t, expr)
+		}
+	}
+}
+
+func TestOptTableNotNullConstraintColumns(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	desc := makeTestTableDesc(t, `
+		CREATE TABLE t (
+			k INT PRIMARY KEY,
+			a INT CHECK (a > 0),
+			b INT CHECK (b IS NOT NULL),
+			c INT,
+			d INT,
+			e INT,
+			CHECK (c IS NOT NULL AND (d IS DISTINCT FROM NULL)),
+			CHECK (e IS NOT NULL OR c > 0)
+		)
+	`)
+	// Unvalidated constraints may not hold on existing rows.
+	desc.Checks = append(desc.Checks, &descpb.TableDescriptor_CheckConstraint{
+		Name:     "unvalidated",
+		Expr:     "e IS NOT NULL",
+		Validity: descpb.ConstraintValidity_Unvalidated,
+	})
+	ot := makeTestOptTable(t, desc)
+
+	// CHECK (a > 0) passes when a is NULL, so it doesn't imply that a is not
+	// NULL. Neither does a disjunction.
+	var expected util.FastIntSet
+	expected.Add(2) // b
+	expected.Add(3) // c
+	expected.Add(4) // d
+	require.True(t, expected.Equals(ot.NotNullConstraintColumns()),
+		"expected %s, got %s", expected, ot.NotNullConstraintColumns())
+
+	// Tables without such constraints have an empty set.
+	ot = makeTestOptTable(t, makeTestTableDesc(t, `CREATE TABLE t (k INT PRIMARY KEY, x INT CHECK (x > 0))`))
+	require.True(t, ot.NotNullConstraintColumns().Empty())
+}
+
+func TestOptTableFamilyColumnSet(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	testCases := []struct {
+		name     string
+		schema   string
+		families [][]int
+	}{
+		{
+			name: "multiple families",
+			schema: `
+				CREATE TABLE t (
+					a INT PRIMARY KEY,
+					b INT,
+					c INT,
+					d INT,
+					FAMILY f1 (a, c),
+					FAMILY f2 (b),
+					FAMILY f3 (d)
+				)
+			`,
+			families: [][]int{{0, 2}, {1}, {3}},
+		},
+		{
+			name:     "synthesized primary family",
+			schema:   `CREATE TABLE t (a INT PRIMARY KEY, b INT, c INT)`,
+			families: [][]int{{0, 1, 2}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ot := makeTestOptTable(t, makeTestTableDesc(t, tc.schema))
+			require.Equal(t, len(tc.families), ot.FamilyCount())
+			for i, expected := range tc.families {
+				require.True(t, util.MakeFastIntSet(expected...).Equals(ot.FamilyColumnSet(i)),
+					"family %d: %s", i, ot.FamilyColumnSet(i))
+			}
+		})
+	}
+}
+
+func TestOptIndexIsReadable(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	desc := makeTestTableDesc(t, `
+		CREATE TABLE t (
+			a INT PRIMARY KEY,
+			b INT,
+			c INT,
+			INDEX full_idx (b),
+			INDEX public_partial (c) WHERE b > 0,
+			INDEX backfilling_partial (c) WHERE b < 0,
+			INDEX delete_only (b, c)
+		)
+	`)
+	makeTestIndexMutation(
+		t, desc, "backfilling_partial", descpb.DescriptorMutation_DELETE_AND_WRITE_ONLY, descpb.DescriptorMutation_ADD,
+	)
+	makeTestIndexMutation(
+		t, desc, "delete_only", descpb.DescriptorMutation_DELETE_ONLY, descpb.DescriptorMutation_DROP,
+	)
+	ot := makeTestOptTable(t, desc)
+
+	expected := map[tree.Name]bool{
+		"primary":             true,
+		"full_idx":            true,
+		"public_partial":      true,
+		"backfilling_partial": false,
+		"delete_only":         false,
+	}
+	require.Equal(t, len(expected), ot.DeletableIndexCount())
+	for i := 0; i < ot.DeletableIndexCount(); i++ {
+		idx := ot.Index(i).(*optIndex)
+		require.Equal(t, expected[idx.Name()], idx.IsReadable(), "index %s", idx.Name())
+	}
+
+	// No index of a table that is not public is readable, although its public
+	// indexes are still valid.
+	for _, state := range []descpb.DescriptorState{
+		descpb.DescriptorState_ADD, descpb.DescriptorState_DROP, descpb.DescriptorState_OFFLINE,
+	} {
+		desc.State = state
+		ot := makeTestOptTable(t, desc)
+		idx := ot.Index(cat.PrimaryIndex).(*optIndex)
+		require.True(t, idx.IsValid(), "state %s", state)
+		require.False(t, idx.IsReadable(), "state %s", state)
+	}
+}
+
+func TestOptTableColumnByID(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	desc := makeTestTableDesc(t, `CREATE TABLE t (a INT PRIMARY KEY, b INT, c STRING)`)
+	tn := tree.MakeTableNameWithSchema("", "crdb_internal", "t")
+	vt, err := newOptVirtualTable(
+		context.Background(), nil /* oc */, tabledesc.NewImmutable(*desc.TableDesc()), &tn,
+	)
+	require.NoError(t, err)
+
+	for _, tab := range []cat.Table{makeTestOptTable(t, desc), vt} {
+		t.Run(fmt.Sprintf("%T", tab), func(t *testing.T) {
+			for i := 0; i < tab.ColumnCount(); i++ {
+				expected := tab.Column(i)
+				if expected.Kind().IsVirtual() {
+					continue
+				}
+				col, ok := tab.ColumnByID(expected.ColID())
+				require.True(t, ok)
+				require.Same(t, expected, col)
+			}
+
+			col, ok := tab.ColumnByID(3)
+			require.True(t, ok)
+			require.Equal(t, tree.Name("c"), col.ColName())
+
+			col, ok = tab.ColumnByID(100)
+			require.False(t, ok)
+			require.Nil(t, col)
+		})
+	}
+}
+
+func TestUnwrapDescriptor(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	srv, cleanup := startOptCatalogTestServer(t, `
+		CREATE DATABASE t;
+		CREATE TABLE t.tab (k INT PRIMARY KEY);
+		CREATE VIEW t.v AS SELECT k FROM t.tab;
+		CREATE SEQUENCE t.seq;
+	`)
+	defer cleanup()
+
+	for _, name := range []tree.Name{"tab", "v", "seq"} {
+		t.Run(string(name), func(t *testing.T) {
+			ds := srv.resolve(t, name)
+			desc, err := UnwrapDescriptor(ds)
+			require.NoError(t, err)
+			require.Equal(t, ds.ID(), cat.StableID(desc.GetID()))
+			require.Equal(t, string(name), desc.GetName())
+		})
+	}
+
+	t.Run("virtual", func(t *testing.T) {
+		ds := srv.resolveInSchema(t, "pg_catalog", "pg_class")
+		desc, err := UnwrapDescriptor(ds)
+		require.NoError(t, err)
+		require.True(t, desc.IsVirtualTable())
+		require.Equal(t, "pg_class", desc.GetName())
+	})
+}
+
+func TestOptIndexEstimatedSize(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	desc := makeTestTableDesc(t, `
+		CREATE TABLE t (
+			a INT PRIMARY KEY,
+			b INT,
+			c STRING,
+			d STRING,
+			INDEX narrow (b),
+			INDEX wide (b) STORING (c, d)
+		)
+	`)
+	makeTable := func(tableStats ...*stats.TableStatistic) *optTable {
+		ot, err := newOptTable(
+			tabledesc.NewImmutable(*desc.TableDesc()),
+			keys.SystemSQLCodec,
+			&cluster.MakeTestingClusterSettings().SV,
+			tableStats,
+			emptyZoneConfig,
+		)
+		require.NoError(t, err)
+		return ot
+	}
+
+	t.Run("no stats", func(t *testing.T) {
+		ot := makeTable()
+		for i := 0; i < ot.IndexCount(); i++ {
+			_, ok := ot.Index(i).(*optIndex).EstimatedSize()
+			require.False(t, ok)
+		}
+	})
+
+	t.Run("stats", func(t *testing.T) {
+		ot := makeTable(&stats.TableStatistic{TableStatisticProto: stats.TableStatisticProto{
+			ColumnIDs: []descpb.ColumnID{1},
+			RowCount:  1000,
+		}})
+		sizes := make(map[tree.Name]uint64)
+		for i := 0; i < ot.IndexCount(); i++ {
+			idx := ot.Index(i).(*optIndex)
+			size, ok := idx.EstimatedSize()
+			require.True(t, ok)
+			sizes[idx.Name()] = size
+		}
+		// The narrow index contains two INT8 columns: b and the primary key a.
+		require.Equal(t, uint64(1000*16), sizes["narrow"])
+		require.Greater(t, sizes["wide"], sizes["narrow"])
+		// The primary index covers the same columns as the wide index.
+		require.Equal(t, sizes["wide"], sizes["primary"])
+	})
+}
+
+func TestOptTableBestCoveringIndex(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// The wide_idx and narrow_idx indexes have the same number of columns, but
+	// wide_idx stores a STRING column rather than an INT column.
+	desc := makeTestTableDesc(t, `
+		CREATE TABLE t (
+			a INT PRIMARY KEY,
+			b INT,
+			c STRING,
+			d INT,
+			j JSONB,
+			INDEX wide_idx (c),
+			INDEX narrow_idx (b),
+			INDEX partial_idx (d) WHERE d > 0,
+			INVERTED INDEX j_idx (j)
+		)
+	`)
+	makeTable := func(tableStats ...*stats.TableStatistic) *optTable {
+		ot, err := newOptTable(
+			tabledesc.NewImmutable(*desc.TableDesc()),
+			keys.SystemSQLCodec,
+			&cluster.MakeTestingClusterSettings().SV,
+			tableStats,
+			emptyZoneConfig,
+		)
+		require.NoError(t, err)
+		return ot
+	}
+	tableStats := &stats.TableStatistic{TableStatisticProto: stats.TableStatisticProto{
+		ColumnIDs: []descpb.ColumnID{1},
+		RowCount:  1000,
+	}}
+
+	// Column ordinals are a=0, b=1, c=2, d=3, j=4.
+	testCases := []struct {
+		cols     util.FastIntSet
+		stats    bool
+		expected tree.Name
+	}{
+		// Both secondary indexes cover a, and without statistics the lower
+		// ordinal wins.
+		{cols: util.MakeFastIntSet(0), expected: "wide_idx"},
+		// With statistics, the smaller index wins.
+		{cols: util.MakeFastIntSet(0), stats: true, expected: "narrow_idx"},
+		// The narrow index beats the primary index, which also covers b.
+		{cols: util.MakeFastIntSet(1), expected: "narrow_idx"},
+		{cols: util.MakeFastIntSet(0, 1), stats: true, expected: "narrow_idx"},
+		{cols: util.MakeFastIntSet(2), expected: "wide_idx"},
+		// Partial and inverted indexes are never chosen.
+		{cols: util.MakeFastIntSet(3), expected: "primary"},
+		{cols: util.MakeFastIntSet(4), expected: "primary"},
+		{cols: util.MakeFastIntSet(1, 2), expected: "primary"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("%s/stats=%t", tc.cols, tc.stats), func(t *testing.T) {
+			ot := makeTable()
+			if tc.stats {
+				ot = makeTable(tableStats)
+			}
+			idx, ok := ot.BestCoveringIndex(tc.cols)
+			require.True(t, ok)
+			require.Equal(t, tc.expected, idx.Name())
+		})
+	}
+
+	t.Run("not covered", func(t *testing.T) {
+		// The inverted column of j_idx is not covered by any non-inverted index.
+		ot := makeTable()
+		invertedCol := ot.Index(4).VirtualInvertedColumn().Ordinal()
+		_, ok := ot.BestCoveringIndex(util.MakeFastIntSet(invertedCol))
+		require.False(t, ok)
+	})
+}
+
+func TestOptTableVisibleColumns(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// The table has a hidden rowid column, another hidden column, a write-only
+	// column, an inverted index virtual column and system columns.
+	desc := makeTestTableDesc(t, `
+		CREATE TABLE t (
+			a INT,
+			h INT,
+			b JSONB,
+			c INT,
+			m INT,
+			INVERTED INDEX (b)
+		)
+	`)
+	for i := range desc.Columns {
+		if desc.Columns[i].Name == "h" {
+			desc.Columns[i].Hidden = true
+		}
+	}
+	for i := range desc.Columns {
+		if desc.Columns[i].Name == "m" {
+			col := desc.Columns[i]
+			desc.Columns = append(desc.Columns[:i:i], desc.Columns[i+1:]...)
+			desc.Mutations = append(desc.Mutations, descpb.DescriptorMutation{
+				Descriptor_: &descpb.DescriptorMutation_Column{Column: &col},
+				State:       descpb.DescriptorMutation_DELETE_AND_WRITE_ONLY,
+				Direction:   descpb.DescriptorMutation_ADD,
+			})
+			break
+		}
+	}
+	tn := tree.MakeTableNameWithSchema("", "crdb_internal", "t")
+	vt, err := newOptVirtualTable(
+		context.Background(), nil /* oc */, tabledesc.NewImmutable(*desc.TableDesc()), &tn,
+	)
+	require.NoError(t, err)
+
+	for _, tab := range []cat.Table{makeTestOptTable(t, desc), vt} {
+		t.Run(fmt.Sprintf("%T", tab), func(t *testing.T) {
+			var names []tree.Name
+			for i := 0; i < tab.VisibleColumnCount(); i++ {
+				names = append(names, tab.VisibleColumn(i).ColName())
+			}
+			require.Equal(t, []tree.Name{"a", "b", "c"}, names)
+		})
+	}
+}
+
+func TestOptCatalogFromCache(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	srv, cleanup := startOptCatalogTestServer(t, `
+		CREATE DATABASE t;
+		CREATE TABLE t.tab (k INT PRIMARY KEY);
+		CREATE VIEW t.v AS SELECT k FROM t.tab;
+		CREATE SEQUENCE t.seq;
+	`)
+	defer cleanup()
+
+	type fromCacher interface {
+		FromCache() bool
+	}
+	for _, name := range []tree.Name{"tab", "v", "seq"} {
+		t.Run(string(name), func(t *testing.T) {
+			tn := tree.MakeTableNameWithSchema("t", tree.PublicSchemaName, name)
+			ds, _, err := srv.oc.ResolveDataSource(ctx, cat.Flags{NoTableStats: true}, &tn)
+			require.NoError(t, err)
+			require.True(t, ds.(fromCacher).FromCache())
+
+			ds, _, err = srv.oc.ResolveDataSource(
+				ctx, cat.Flags{NoTableStats: true, AvoidDescriptorCaches: true}, &tn,
+			)
+			require.NoError(t, err)
+			require.False(t, ds.(fromCacher).FromCache())
+
+			ds, _, err = srv.oc.ResolveDataSourceByID(
+				ctx, cat.Flags{AvoidDescriptorCaches: true}, ds.ID(),
+			)
+			require.NoError(t, err)
+			require.False(t, ds.(fromCacher).FromCache())
+		})
+	}
+}
+
+func TestOptCatalogReserveValues(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	srv, cleanup := startOptCatalogTestServer(t, `
+		CREATE DATABASE t;
+		CREATE SEQUENCE t.asc_seq;
+		CREATE SEQUENCE t.desc_seq INCREMENT -2;
+		CREATE SEQUENCE t.bounded_seq MAXVALUE 5;
+		CREATE SEQUENCE t.virtual_seq VIRTUAL;
+	`)
+	defer cleanup()
+
+	resolveSeq := func(name tree.Name) cat.Sequence {
+		tn := tree.MakeTableNameWithSchema("t", tree.PublicSchemaName, name)
+		ds, _, err := srv.oc.ResolveDataSource(ctx, cat.Flags{}, &tn)
+		require.NoError(t, err)
+		return ds.(cat.Sequence)
+	}
+
+	t.Run("ascending", func(t *testing.T) {
+		seq := resolveSeq("asc_seq")
+		start, err := srv.oc.ReserveValues(ctx, seq, 10)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), start)
+		start, err = srv.oc.ReserveValues(ctx, seq, 5)
+		require.NoError(t, err)
+		require.Equal(t, int64(11), start)
+		srv.r.CheckQueryResults(t, `SELECT nextval('t.asc_seq')`, [][]string{{"16"}})
+	})
+
+	t.Run("descending", func(t *testing.T) {
+		seq := resolveSeq("desc_seq")
+		start, err := srv.oc.ReserveValues(ctx, seq, 3)
+		require.NoError(t, err)
+		require.Equal(t, int64(-1), start)
+		srv.r.CheckQueryResults(t, `SELECT nextval('t.desc_seq')`, [][]string{{"-7"}})
+	})
+
+	t.Run("bounds", func(t *testing.T) {
+		seq := resolveSeq("bounded_seq")
+		start, err := srv.oc.ReserveValues(ctx, seq, 3)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), start)
+		// Reserving values 4 through 6 exceeds the maximum value; sequences don't
+		// support CYCLE, so the values don't wrap around.
+		_, err = srv.oc.ReserveValues(ctx, seq, 3)
+		require.Error(t, err)
+		require.Equal(t, pgcode.SequenceGeneratorLimitExceeded, pgerror.GetPGCode(err))
+		_, err = srv.oc.ReserveValues(ctx, seq, math.MaxInt64)
+		require.Error(t, err)
+		require.Equal(t, pgcode.SequenceGeneratorLimitExceeded, pgerror.GetPGCode(err))
+	})
+
+	t.Run("virtual", func(t *testing.T) {
+		// Like nextval, a single value of a virtual sequence is a unique_rowid().
+		start, err := srv.oc.ReserveValues(ctx, resolveSeq("virtual_seq"), 1)
+		require.NoError(t, err)
+		require.Greater(t, start, int64(0))
+		_, err = srv.oc.ReserveValues(ctx, resolveSeq("virtual_seq"), 3)
+		require.Error(t, err)
+		require.Equal(t, pgcode.FeatureNotSupported, pgerror.GetPGCode(err))
+	})
+}
+
+func TestOptCatalogHasActiveChangefeed(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	s, sqlDB, kvDB := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(ctx)
+
+	r := sqlutils.MakeSQLRunner(sqlDB)
+	r.Exec(t, `
+		CREATE DATABASE t;
+		CREATE TABLE t.watched (k INT PRIMARY KEY);
+		CREATE TABLE t.finished (k INT PRIMARY KEY);
+		CREATE TABLE t.unwatched (k INT PRIMARY KEY);
+	`)
+
+	// Changefeeds can't be created without CCL code, so insert the job records
+	// directly. The active job is paused so that the job registry doesn't try to
+	// adopt it.
+	addChangefeedJob := func(id int64, status jobs.Status, table string) {
+		tableID := catalogkv.TestingGetTableDescriptor(kvDB, keys.SystemSQLCodec, "t", table).GetID()
+		payload, err := protoutil.Marshal(&jobspb.Payload{
+			Details: jobspb.WrapPayloadDetails(jobspb.ChangefeedDetails{
+				Targets: jobspb.ChangefeedTargets{
+					tableID: jobspb.ChangefeedTarget{StatementTimeName: table},
+				},
+			}),
+		})
+		require.NoError(t, err)
+		r.Exec(t, `INSERT INTO system.jobs (id, status, payload) VALUES ($1, $2, $3)`,
+			id, string(status), payload)
+	}
+	addChangefeedJob(1, jobs.StatusPaused, "watched")
+	addChangefeedJob(2, jobs.StatusFailed, "finished")
+
+	// A paused job of another type doesn't count, even if it refers to the
+	// table.
+	unwatchedID := catalogkv.TestingGetTableDescriptor(kvDB, keys.SystemSQLCodec, "t", "unwatched").GetID()
+	payload, err := protoutil.Marshal(&jobspb.Payload{
+		DescriptorIDs: []descpb.ID{unwatchedID},
+		Details:       jobspb.WrapPayloadDetails(jobspb.SchemaChangeDetails{}),
+	})
+	require.NoError(t, err)
+	r.Exec(t, `INSERT INTO system.jobs (id, status, payload) VALUES ($1, $2, $3)`,
+		3, string(jobs.StatusPaused), payload)
+
+	oc, cleanup := makeTestOptCatalog(ctx, s, kvDB)
+	defer cleanup()
+
+	testCases := []struct {
+		table    tree.Name
+		expected bool
+	}{
+		{table: "watched", expected: true},
+		{table: "finished", expected: false},
+		{table: "unwatched", expected: false},
+	}
+	for _, tc := range testCases {
+		t.Run(string(tc.table), func(t *testing.T) {
+			tn := tree.MakeTableNameWithSchema("t", tree.PublicSchemaName, tc.table)
+			ds, _, err := oc.ResolveDataSource(ctx, cat.Flags{NoTableStats: true}, &tn)
+			require.NoError(t, err)
+			// The second call is served from the per-query cache.
+			for i := 0; i < 2; i++ {
+				active, err := oc.HasActiveChangefeed(ctx, ds.(cat.Table))
+				require.NoError(t, err)
+				require.Equal(t, tc.expected, active)
+			}
+		})
+	}
+}
+
+func TestOptTablePendingPrimaryIndex(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	const schema = `CREATE TABLE t (a INT PRIMARY KEY, b INT NOT NULL, INDEX b_idx (b))`
+
+	t.Run("no swap", func(t *testing.T) {
+		ot := makeTestOptTable(t, makeTestTableDesc(t, schema))
+		require.False(t, ot.PrimaryKeySwapInProgress())
+		idx, ok := ot.PendingPrimaryIndex()
+		require.False(t, ok)
+		require.Nil(t, idx)
+	})
+
+	t.Run("swap", func(t *testing.T) {
+		// Simulate ALTER PRIMARY KEY USING COLUMNS (b), which adds the new
+		// primary index as a mutation along with the swap mutation.
+		desc := makeTestTableDesc(t, schema)
+		desc.Indexes = append(desc.Indexes, descpb.IndexDescriptor{
+			Name:             "new_primary_key",
+			ID:               desc.NextIndexID,
+			Unique:           true,
+			ColumnNames:      []string{"b"},
+			ColumnIDs:        []descpb.ColumnID{2},
+			ColumnDirections: []descpb.IndexDescriptor_Direction{descpb.IndexDescriptor_ASC},
+			StoreColumnNames: []string{"a"},
+			StoreColumnIDs:   []descpb.ColumnID{1},
+		})
+		newPrimaryIndexID := desc.NextIndexID
+		desc.NextIndexID++
+		makeTestIndexMutation(
+			t, desc, "new_primary_key", descpb.DescriptorMutation_DELETE_AND_WRITE_ONLY, descpb.DescriptorMutation_ADD,
+		)
+		desc.AddPrimaryKeySwapMutation(&descpb.PrimaryKeySwap{
+			OldPrimaryIndexId: desc.PrimaryIndex.ID,
+			NewPrimaryIndexId: newPrimaryIndexID,
+		})
+		ot := makeTestOptTable(t, desc)
+
+		require.True(t, ot.PrimaryKeySwapInProgress())
+		idx, ok := ot.PendingPrimaryIndex()
+		require.True(t, ok)
+		require.Equal(t, cat.StableID(newPrimaryIndexID), idx.ID())
+		require.Equal(t, tree.Name("new_primary_key"), idx.Name())
+		// The pending primary index is a mutation index, and the current primary
+		// index is unchanged.
+		require.True(t, cat.IsMutationIndex(ot, idx.Ordinal()))
+		require.Equal(t, tree.Name("primary"), ot.Index(cat.PrimaryIndex).Name())
+	})
+}
+
+func TestOptTableColumnIsInaccessible(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// The table has a hidden rowid column and an inverted index key column.
+	ot := makeTestOptTable(t, makeTestTableDesc(t, `CREATE TABLE t (a INT, j JSONB, INVERTED INDEX (j))`))
+
+	testCases := []struct {
+		col          tree.Name
+		hidden       bool
+		inaccessible bool
+	}{
+		{col: "a", hidden: false, inaccessible: false},
+		{col: "rowid", hidden: true, inaccessible: false},
+		{col: "j_inverted_key", hidden: true, inaccessible: true},
+	}
+	for _, tc := range testCases {
+		t.Run(string(tc.col), func(t *testing.T) {
+			var col *cat.Column
+			for i := 0; i < ot.ColumnCount(); i++ {
+				if ot.Column(i).ColName() == tc.col {
+					col = ot.Column(i)
+				}
+			}
+			require.NotNil(t, col)
+			require.Equal(t, tc.hidden, col.IsHidden())
+			require.Equal(t, tc.inaccessible, col.IsInaccessible())
+		})
+	}
+}
+
+func TestOptCatalogResolveTableRecordType(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	srv, cleanup := startOptCatalogTestServer(t, `
+		CREATE DATABASE t;
+		CREATE TABLE t.tab (a INT, b STRING, c DECIMAL(10, 2), d BOOL[]);
+		CREATE VIEW t.v AS SELECT a FROM t.tab;
+	`)
+	defer cleanup()
+
+	getID := func(name string) cat.StableID {
+		desc := catalogkv.TestingGetTableDescriptor(srv.kvDB, keys.SystemSQLCodec, "t", name)
+		return cat.StableID(desc.GetID())
+	}
+
+	// The hidden rowid column is not part of the record type.
+	typ, err := srv.oc.ResolveTableRecordType(ctx, getID("tab"))
+	require.NoError(t, err)
+	require.Equal(t, types.TupleFamily, typ.Family())
+	require.Equal(t, []string{"a", "b", "c", "d"}, typ.TupleLabels())
+	expected := []*types.T{
+		types.Int, types.String, types.MakeDecimal(10, 2), types.MakeArray(types.Bool),
+	}
+	require.Len(t, typ.TupleContents(), len(expected))
+	for i := range expected {
+		require.True(t, expected[i].Identical(typ.TupleContents()[i]),
+			"expected %s, got %s", expected[i].SQLString(), typ.TupleContents()[i].SQLString())
+	}
+
+	_, err = srv.oc.ResolveTableRecordType(ctx, getID("v"))
+	require.Error(t, err)
+	require.Equal(t, pgcode.WrongObjectType, pgerror.GetPGCode(err))
+}
+
+func TestOptTableIsSingleFamily(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	testCases := []struct {
+		schema   string
+		expected bool
+	}{
+		// The primary family is synthesized.
+		{schema: `CREATE TABLE t (a INT PRIMARY KEY, b INT)`, expected: true},
+		{schema: `CREATE TABLE t (a INT PRIMARY KEY, b INT, FAMILY f (a, b))`, expected: true},
+		{schema: `CREATE TABLE t (a INT PRIMARY KEY, b INT, FAMILY f1 (a), FAMILY f2 (b))`, expected: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.schema, func(t *testing.T) {
+			ot := makeTestOptTable(t, makeTestTableDesc(t, tc.schema))
+			require.Equal(t, tc.expected, ot.IsSingleFamily())
+		})
+	}
+}
+
+func TestOptIndexProvidesOrdering(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// Column ordinals: a=0, b=1, c=2, j=3.
+	ot := makeTestOptTable(t, makeTestTableDesc(t, `
+		CREATE TABLE t (
+			a INT PRIMARY KEY,
+			b INT,
+			c INT,
+			j JSONB,
+			INDEX b_c_idx (b ASC, c DESC),
+			INVERTED INDEX j_idx (j)
+		)
+	`))
+	asc := func(ord int) cat.IndexColumnOrder {
+		return cat.IndexColumnOrder{Ordinal: ord}
+	}
+	desc := func(ord int) cat.IndexColumnOrder {
+		return cat.IndexColumnOrder{Ordinal: ord, Descending: true, NullsLast: true}
+	}
+	ordering := func(cols ...cat.IndexColumnOrder) []cat.IndexColumnOrder {
+		return cols
+	}
+
+	testCases := []struct {
+		index    cat.IndexOrdinal
+		ordering []cat.IndexColumnOrder
+		expected bool
+	}{
+		{index: 1, ordering: ordering(), expected: true},
+		// Satisfied, including the implicit primary key column.
+		{index: 1, ordering: ordering(asc(1)), expected: true},
+		{index: 1, ordering: ordering(asc(1), desc(2)), expected: true},
+		{index: 1, ordering: ordering(asc(1), desc(2), asc(0)), expected: true},
+		// Partially satisfied: the prefix matches, but not the whole ordering.
+		{index: 1, ordering: ordering(asc(1), asc(2)), expected: false},
+		{index: 1, ordering: ordering(asc(1), desc(2), asc(0), asc(3)), expected: false},
+		// The NULL ordering doesn't match the direction.
+		{index: 1, ordering: ordering(cat.IndexColumnOrder{Ordinal: 1, NullsLast: true}), expected: false},
+		// Not satisfied.
+		{index: 1, ordering: ordering(asc(2)), expected: false},
+		{index: 1, ordering: ordering(desc(1)), expected: false},
+		{index: cat.PrimaryIndex, ordering: ordering(asc(0)), expected: true},
+		{index: cat.PrimaryIndex, ordering: ordering(asc(0), asc(1)), expected: false},
+		{index: 2, ordering: ordering(asc(3)), expected: false},
+	}
+	for i, tc := range testCases {
+		idx := ot.Index(tc.index).(*optIndex)
+		require.Equal(t, tc.expected, idx.ProvidesOrdering(tc.ordering), "test case %d", i)
+	}
+}
+
+func TestOptCatalogWarm(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	srv, cleanup := startOptCatalogTestServer(t, `
+		CREATE DATABASE t;
+		CREATE TABLE t.x (k INT PRIMARY KEY);
+		CREATE VIEW t.v AS SELECT k FROM t.x;
+	`)
+	defer cleanup()
+
+	x := catalogkv.TestingGetTableDescriptor(srv.kvDB, keys.SystemSQLCodec, "t", "x")
+	v := catalogkv.TestingGetTableDescriptor(srv.kvDB, keys.SystemSQLCodec, "t", "v")
+	ids := []cat.StableID{cat.StableID(x.GetID()), cat.StableID(v.GetID())}
+
+	require.Len(t, srv.oc.dataSources, 0)
+	require.NoError(t, srv.oc.Warm(ctx, ids))
+	require.Len(t, srv.oc.dataSources, 2)
+	warmed := make(map[cat.DataSource]bool)
+	for _, ds := range srv.oc.dataSources {
+		warmed[ds] = true
+	}
+
+	// Subsequent resolutions return the cached wrappers.
+	for _, id := range ids {
+		ds, _, err := srv.oc.ResolveDataSourceByID(ctx, cat.Flags{}, id)
+		require.NoError(t, err)
+		require.True(t, warmed[ds], "data source %d was not cached", id)
+	}
+	require.Len(t, srv.oc.dataSources, 2)
+
+	err := srv.oc.Warm(ctx, []cat.StableID{12345})
+	require.Error(t, err)
+	require.Equal(t, pgcode.UndefinedTable, pgerror.GetPGCode(err))
+}
+
+func TestOptTableColumnDefaultSequenceID(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	desc := makeTestTableDesc(t, `
+		CREATE TABLE t (
+			k INT PRIMARY KEY,
+			ts TIMESTAMPTZ DEFAULT now(),
+			c INT DEFAULT 1,
+			d INT
+		)
+	`)
+	// Resolving the sequence of a SERIAL column needs a catalog, so k is given
+	// the default and sequence reference that such a column would have. c
+	// references two sequences, and d references a sequence without having a
+	// default.
+	const seqID = 200
+	serialDefault := "nextval('t_k_seq':::STRING)"
+	desc.Columns[0].DefaultExpr = &serialDefault
+	desc.Columns[0].UsesSequenceIds = []descpb.ID{seqID}
+	desc.Columns[2].UsesSequenceIds = []descpb.ID{seqID, seqID + 1}
+	desc.Columns[3].UsesSequenceIds = []descpb.ID{seqID}
+	tab := makeTestOptTable(t, desc)
+
+	testCases := []struct {
+		ord      int
+		expected cat.StableID
+		ok       bool
+	}{
+		{ord: 0 /* k */, expected: seqID, ok: true},
+		{ord: 1 /* ts */, ok: false},
+		{ord: 2 /* c */, ok: false},
+		{ord: 3 /* d */, ok: false},
+	}
+	for _, tc := range testCases {
+		col := tab.Column(tc.ord)
+		seq, ok := col.DefaultSequenceID()
+		require.Equal(t, tc.ok, ok, "column %s", col.ColName())
+		require.Equal(t, tc.expected, seq, "column %s", col.ColName())
+	}
+}
+
+func TestOptTablePartitionKeyPrefix(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	encodeValue := func(s string) []byte {
+		value, err := rowenc.EncodeTableValue(
+			nil /* appendTo */, descpb.ColumnID(encoding.NoColumnID), tree.NewDString(s), nil, /* scratch */
+		)
+		require.NoError(t, err)
+		return value
+	}
+	defaultValue := encoding.EncodeNotNullValue(nil /* appendTo */, encoding.NoColumnID)
+	defaultValue = encoding.EncodeNonsortingUvarint(defaultValue, uint64(rowenc.PartitionDefaultVal))
+
+	// Partitioning requires a CCL license to set up through SQL, so the
+	// partitioning descriptor is crafted directly.
+	desc := makeTestTableDesc(t, `
+		CREATE TABLE t (region STRING, k INT, v INT, PRIMARY KEY (region, k), INDEX v_idx (v))
+	`)
+	desc.PrimaryIndex.Partitioning = descpb.PartitioningDescriptor{
+		NumColumns: 1,
+		List: []descpb.PartitioningDescriptor_List{
+			{Name: "us", Values: [][]byte{encodeValue("us")}},
+			{Name: "europe", Values: [][]byte{encodeValue("eu"), encodeValue("uk")}},
+			{Name: "other", Values: [][]byte{defaultValue}},
+			{Name: "corrupt", Values: [][]byte{{}}},
+		},
+	}
+	ot := makeTestOptTable(t, desc)
+	span := ot.Index(cat.PrimaryIndex).Span()
+
+	key, ok, err := ot.PartitionKeyPrefix(cat.PrimaryIndex, "us")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.True(t, bytes.HasPrefix(key, span.Key))
+	require.True(t, span.ContainsKey(key))
+	require.Equal(t, encoding.EncodeStringAscending(nil /* b */, "us"), []byte(key[len(span.Key):]))
+
+	// The DEFAULT partition has the prefix of the whole index.
+	key, ok, err = ot.PartitionKeyPrefix(cat.PrimaryIndex, "other")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, span.Key, key)
+
+	// A partition with several values has no single prefix.
+	_, ok, err = ot.PartitionKeyPrefix(cat.PrimaryIndex, "europe")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	_, ok, err = ot.PartitionKeyPrefix(cat.PrimaryIndex, "missing")
+	require.NoError(t, err)
+	require.False(t, ok)
+	_, ok, err = ot.PartitionKeyPrefix(1, "us")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// A value that can't be decoded results in an error rather than a panic.
+	_, _, err = ot.PartitionKeyPrefix(cat.PrimaryIndex, "corrupt")
+	require.Error(t, err)
+}
+
+func TestOptTableTemporarySessionID(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	s, sqlDB, kvDB := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(ctx)
+
+	// Temporary objects are removed when their session ends, so they are
+	// created on a dedicated connection that stays open.
+	conn, err := sqlDB.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+	r := sqlutils.MakeSQLRunner(conn)
+	r.Exec(t, `
+		SET experimental_enable_temp_tables = true;
+		CREATE TABLE perm (k INT PRIMARY KEY);
+		CREATE TEMP TABLE temp (k INT PRIMARY KEY);
+	`)
+	var sessionID, tempSchema string
+	r.QueryRow(t, `SHOW session_id`).Scan(&sessionID)
+	r.QueryRow(t,
+		`SELECT table_schema FROM information_schema.tables WHERE table_name = 'temp'`,
+	).Scan(&tempSchema)
+
+	oc, cleanup := makeTestOptCatalog(ctx, s, kvDB)
+	defer cleanup()
+
+	resolve := func(schema, name string) cat.Table {
+		tn := tree.MakeTableNameWithSchema("defaultdb", tree.Name(schema), tree.Name(name))
+		ds, _, err := oc.ResolveDataSource(ctx, cat.Flags{NoTableStats: true}, &tn)
+		require.NoError(t, err)
+		return ds.(cat.Table)
+	}
+
+	id, ok := resolve(tempSchema, "temp").(*optTable).TemporarySessionID()
+	require.True(t, ok)
+	require.Equal(t, sessionID, id)
+
+	_, ok = resolve(tree.PublicSchema, "perm").(*optTable).TemporarySessionID()
+	require.False(t, ok)
+
+	resolveSchema := func(name string) *optSchema {
+		sn := cat.SchemaName{
+			CatalogName:     "defaultdb",
+			SchemaName:      tree.Name(name),
+			ExplicitCatalog: true,
+			ExplicitSchema:  true,
+		}
+		sc, _, err := oc.ResolveSchema(ctx, cat.Flags{}, &sn)
+		require.NoError(t, err)
+		return sc.(*optSchema)
+	}
+
+	id, ok = resolveSchema(tempSchema).TemporarySessionID()
+	require.True(t, ok)
+	require.Equal(t, sessionID, id)
+
+	_, ok = resolveSchema(tree.PublicSchema).TemporarySessionID()
+	require.False(t, ok)
+}
+
+func TestOptTableUsedUserDefinedTypes(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	srv, cleanup := startOptCatalogTestServer(t, `
+		CREATE DATABASE t;
+		CREATE TYPE t.greeting AS ENUM ('hello', 'hi');
+		CREATE TYPE t.color AS ENUM ('red', 'blue');
+		CREATE TABLE t.scalar (k INT PRIMARY KEY, g t.greeting, g2 t.greeting);
+		CREATE TABLE t.arr (k INT PRIMARY KEY, c t.color[]);
+		CREATE TABLE t.plain (k INT PRIMARY KEY, s STRING, a INT[]);
+	`)
+	defer cleanup()
+
+	var greetingOID, colorOID, colorArrayOID oid.Oid
+	srv.r.QueryRow(t, `SELECT 't.public.greeting'::REGTYPE::OID`).Scan(&greetingOID)
+	srv.r.QueryRow(t, `SELECT 't.public.color'::REGTYPE::OID`).Scan(&colorOID)
+	srv.r.QueryRow(t, `SELECT 't.public._color'::REGTYPE::OID`).Scan(&colorArrayOID)
+
+	testCases := []struct {
+		table    string
+		expected []oid.Oid
+	}{
+		{table: "scalar", expected: []oid.Oid{greetingOID}},
+		{table: "arr", expected: []oid.Oid{colorArrayOID, colorOID}},
+		{table: "plain", expected: nil},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.table, func(t *testing.T) {
+			tn := tree.MakeTableNameWithSchema("t", tree.PublicSchemaName, tree.Name(tc.table))
+			ds, _, err := srv.oc.ResolveDataSource(ctx, cat.Flags{NoTableStats: true}, &tn)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, ds.(*optTable).UsedUserDefinedTypes())
+		})
+	}
+}
+
+func TestOptCatalogResolveAddingDataSourceByID(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	s, sqlDB, kvDB := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(ctx)
+
+	r := sqlutils.MakeSQLRunner(sqlDB)
+	r.Exec(t, `
+		CREATE DATABASE t;
+		CREATE TABLE t.public_tab (k INT PRIMARY KEY);
+		CREATE TABLE t.adding_tab (k INT PRIMARY KEY, v STRING);
+	`)
+	public := catalogkv.TestingGetTableDescriptor(kvDB, keys.SystemSQLCodec, "t", "public_tab")
+
+	// Put the table back into the ADD state, as if it were still being created.
+	adding := catalogkv.TestingGetMutableExistingTableDescriptor(
+		kvDB, keys.SystemSQLCodec, "t", "adding_tab")
+	adding.State = descpb.DescriptorState_ADD
+	require.NoError(t, kvDB.Txn(ctx, func(ctx context.Context, txn *kv.Txn) error {
+		if err := txn.SetSystemConfigTrigger(true /* forSystemTenant */); err != nil {
+			return err
+		}
+		return txn.Put(
+			ctx, catalogkeys.MakeDescMetadataKey(keys.SystemSQLCodec, adding.ID), adding.DescriptorProto(),
+		)
+	}))
+
+	oc, cleanup := makeTestOptCatalog(ctx, s, kvDB)
+	defer cleanup()
+
+	// The regular resolution doesn't return adding tables.
+	ds, isAdding, err := oc.ResolveDataSourceByID(ctx, cat.Flags{}, cat.StableID(adding.ID))
+	require.Error(t, err)
+	require.True(t, isAdding)
+	require.Nil(t, ds)
+
+	ds, err = oc.ResolveAddingDataSourceByID(ctx, cat.StableID(adding.ID))
+	require.NoError(t, err)
+	tab := ds.(*optTable)
+	require.Equal(t, descpb.DescriptorState_ADD, tab.State())
+	require.Equal(t, tree.Name("adding_tab"), tab.Name())
+	var cols []tree.Name
+	for i := 0; i < tab.ColumnCount(); i++ {
+		if tab.Column(i).Kind() == cat.Ordinary {
+			cols = append(cols, tab.Column(i).ColName())
+		}
+	}
+	require.Equal(t, []tree.Name{"k", "v"}, cols)
+
+	// Public tables are resolved as usual.
+	ds, err = oc.ResolveAddingDataSourceByID(ctx, cat.StableID(public.GetID()))
+	require.NoError(t, err)
+	require.Equal(t, descpb.DescriptorState_PUBLIC, ds.(*optTable).State())
+
+	_, err = oc.ResolveAddingDataSourceByID(ctx, 12345)
+	require.Error(t, err)
+	require.Equal(t, pgcode.UndefinedTable, pgerror.GetPGCode(err))
+}
+
+func TestOptTableHasSecondaryIndexes(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	testCases := []struct {
+		name     string
+		schema   string
+		mutation string
+		expected bool
+	}{
+		{
+			name:     "pk-only",
+			schema:   `CREATE TABLE t (a INT PRIMARY KEY, b INT)`,
+			expected: false,
+		},
+		{
+			name:     "rowid",
+			schema:   `CREATE TABLE t (a INT, b INT)`,
+			expected: false,
+		},
+		{
+			name:     "secondary",
+			schema:   `CREATE TABLE t (a INT PRIMARY KEY, b INT, INDEX b_idx (b))`,
+			expected: true,
+		},
+		{
+			name:     "mutation-only",
+			schema:   `CREATE TABLE t (a INT PRIMARY KEY, b INT, INDEX b_idx (b))`,
+			mutation: "b_idx",
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			desc := makeTestTableDesc(t, tc.schema)
+			if tc.mutation != "" {
+				makeTestIndexMutation(
+					t, desc, tc.mutation, descpb.DescriptorMutation_WRITE_ONLY, descpb.DescriptorMutation_ADD,
+				)
+			}
+			ot := makeTestOptTable(t, desc)
+			require.Equal(t, tc.expected, ot.HasSecondaryIndexes())
+		})
+	}
+}
+
+func TestOptTableIsRowIDColumn(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	testCases := []struct {
+		name   string
+		schema string
+		// rowIDCol is the name of the rowid column, or empty if there is none.
+		rowIDCol tree.Name
+	}{
+		{
+			name:     "rowid",
+			schema:   `CREATE TABLE t (a INT, b INT)`,
+			rowIDCol: "rowid",
+		},
+		{
+			name:     "rowid name taken",
+			schema:   `CREATE TABLE t (a INT, rowid INT)`,
+			rowIDCol: "rowid_1",
+		},
+		{
+			name:   "explicit pk",
+			schema: `CREATE TABLE t (a INT PRIMARY KEY, b INT)`,
+		},
+		{
+			name:   "visible pk defaulting to unique_rowid",
+			schema: `CREATE TABLE t (a INT PRIMARY KEY DEFAULT unique_rowid(), b INT)`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ot := makeTestOptTable(t, makeTestTableDesc(t, tc.schema))
+			found := false
+			for i, n := 0, ot.ColumnCount(); i < n; i++ {
+				isRowID := ot.IsRowIDColumn(i)
+				require.Equal(t, tc.rowIDCol != "" && ot.Column(i).ColName() == tc.rowIDCol, isRowID,
+					"column %s", ot.Column(i).ColName())
+				found = found || isRowID
+			}
+			require.Equal(t, tc.rowIDCol != "", found)
+		})
+	}
+}
+
+func TestOptForeignKeyConstraintColumnTypesMatch(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	srv, cleanup := startOptCatalogTestServer(t, `
+		CREATE DATABASE t;
+		CREATE TABLE t.parent (a INT8, b STRING, PRIMARY KEY (a, b));
+		CREATE TABLE t.same (
+			k INT PRIMARY KEY, a INT8, b STRING, FOREIGN KEY (a, b) REFERENCES t.parent (a, b)
+		);
+		CREATE TABLE t.different (
+			k INT PRIMARY KEY, a INT4, b STRING, FOREIGN KEY (a, b) REFERENCES t.parent (a, b)
+		);
+	`)
+	defer cleanup()
+
+	parent := srv.resolve(t, "parent").(cat.Table)
+	same := srv.resolve(t, "same").(cat.Table)
+	different := srv.resolve(t, "different").(cat.Table)
+
+	fk := same.OutboundForeignKey(0).(*optForeignKeyConstraint)
+	match, err := fk.ColumnTypesMatch(same, parent)
+	require.NoError(t, err)
+	require.True(t, match)
+
+	fk = different.OutboundForeignKey(0).(*optForeignKeyConstraint)
+	match, err = fk.ColumnTypesMatch(different, parent)
+	require.NoError(t, err)
+	require.False(t, match)
+
+	// Passing the wrong tables is an error.
+	_, err = fk.ColumnTypesMatch(parent, different)
+	require.Error(t, err)
+}
+
+func TestOptTableEncodePrimaryKey(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ot := makeTestOptTable(t, makeTestTableDesc(t, `
+		CREATE TABLE t (a INT, b STRING, c INT, PRIMARY KEY (b, a DESC))
+	`))
+	tableSpan := ot.desc.TableSpan(keys.SystemSQLCodec)
+	pkSpan := ot.Index(cat.PrimaryIndex).Span()
+
+	key, err := ot.EncodePrimaryKey(tree.Datums{tree.NewDString("x"), tree.NewDInt(1)})
+	require.NoError(t, err)
+	require.True(t, tableSpan.ContainsKey(key))
+	require.True(t, pkSpan.ContainsKey(key))
+	expected := encoding.EncodeStringAscending(append(roachpb.Key(nil), pkSpan.Key...), "x")
+	expected = encoding.EncodeVarintDescending(expected, 1)
+	require.Equal(t, roachpb.Key(expected), key)
+
+	// Keys are ordered according to the primary index.
+	other, err := ot.EncodePrimaryKey(tree.Datums{tree.NewDString("x"), tree.NewDInt(2)})
+	require.NoError(t, err)
+	require.True(t, other.Compare(key) < 0)
+
+	_, err = ot.EncodePrimaryKey(tree.Datums{tree.NewDString("x")})
+	require.Error(t, err)
+	require.Equal(t, pgcode.InvalidParameterValue, pgerror.GetPGCode(err))
+
+	_, err = ot.EncodePrimaryKey(tree.Datums{tree.NewDInt(1), tree.NewDString("x")})
+	require.Error(t, err)
+	require.Equal(t, pgcode.DatatypeMismatch, pgerror.GetPGCode(err))
+
+	_, err = ot.EncodePrimaryKey(tree.Datums{tree.NewDString("x"), tree.DNull})
+	require.Error(t, err)
+	require.Equal(t, pgcode.NotNullViolation, pgerror.GetPGCode(err))
+}
+
+func TestOptCatalogPublicSchemaCache(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	srv, cleanup := startOptCatalogTestServer(t, `
+		CREATE DATABASE db1;
+		CREATE DATABASE db2;
+		CREATE SCHEMA db1.sc;
+	`)
+	defer cleanup()
+
+	resolveSchema := func(db, schema string) *optSchema {
+		sn := cat.SchemaName{
+			CatalogName:     tree.Name(db),
+			SchemaName:      tree.Name(schema),
+			ExplicitCatalog: true,
+			ExplicitSchema:  true,
+		}
+		sc, resolved, err := srv.oc.ResolveSchema(ctx, cat.Flags{}, &sn)
+		require.NoError(t, err)
+		require.Equal(t, sn, resolved)
+		require.Equal(t, sn, *sc.Name())
+		return sc.(*optSchema)
+	}
+
+	public1 := resolveSchema("db1", tree.PublicSchema)
+	public2 := resolveSchema("db2", tree.PublicSchema)
+	require.Equal(t, "db1", public1.database.GetName())
+	require.Equal(t, "db2", public2.database.GetName())
+	require.NotEqual(t, public1.ID(), public2.ID())
+
+	// Public schemas are cached per database.
+	require.Same(t, public1, resolveSchema("db1", tree.PublicSchema))
+	require.Same(t, public2, resolveSchema("db2", tree.PublicSchema))
+
+	// A different name for the same schema isn't served from the cache.
+	sn := cat.SchemaName{CatalogName: "db1", SchemaName: tree.PublicSchemaName, ExplicitCatalog: true}
+	sc, _, err := srv.oc.ResolveSchema(ctx, cat.Flags{}, &sn)
+	require.NoError(t, err)
+	require.NotSame(t, public1, sc)
+	require.Equal(t, sn, *sc.Name())
+
+	// User-defined and virtual schemas aren't cached.
+	require.NotSame(t, resolveSchema("db1", "sc"), resolveSchema("db1", "sc"))
+	require.NotSame(t, resolveSchema("db1", "pg_catalog"), resolveSchema("db1", "pg_catalog"))
+
+	// The cache is cleared for each query.
+	srv.oc.reset()
+	require.NotSame(t, public2, resolveSchema("db2", tree.PublicSchema))
+}
+
+func BenchmarkOptCatalogResolvePublicSchema(b *testing.B) {
+	defer leaktest.AfterTest(b)()
+	defer log.Scope(b).Close(b)
+
+	ctx := context.Background()
+	srv, cleanup := startOptCatalogTestServer(b, `CREATE DATABASE t`)
+	defer cleanup()
+
+	sn := cat.SchemaName{
+		CatalogName:     "t",
+		SchemaName:      tree.PublicSchemaName,
+		ExplicitCatalog: true,
+		ExplicitSchema:  true,
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := srv.oc.ResolveSchema(ctx, cat.Flags{}, &sn); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestOptTableColumnHasCompositeEncoding(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ot := makeTestOptTable(t, makeTestTableDesc(t, `
+		CREATE TABLE t (
+			d DECIMAL PRIMARY KEY,
+			s STRING COLLATE en,
+			i INT,
+			f FLOAT[],
+			INDEX s_idx (s)
+		)
+	`))
+
+	testCases := []struct {
+		ord       int
+		composite bool
+	}{
+		{ord: 0 /* d */, composite: true},
+		{ord: 1 /* s */, composite: true},
+		{ord: 2 /* i */, composite: false},
+		{ord: 3 /* f */, composite: true},
+	}
+	for _, tc := range testCases {
+		col := ot.Column(tc.ord)
+		require.Equal(t, tc.composite, col.HasCompositeEncoding(), "column %s", col.ColName())
+	}
+}
+
+func TestOptTableIndexesReferencingColumn(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// Column ordinals: k=0, a=1, b=2, c=3, d=4, j=5.
+	ot := makeTestOptTable(t, makeTestTableDesc(t, `
+		CREATE TABLE t (
+			k INT PRIMARY KEY,
+			a INT,
+			b INT,
+			c INT,
+			d INT,
+			j JSONB,
+			INDEX a_idx (a),
+			INDEX b_idx (b) STORING (c),
+			INDEX partial_idx (b) WHERE d > 0,
+			INVERTED INDEX j_idx (j)
+		)
+	`))
+
+	testCases := []struct {
+		col      int
+		expected []string
+	}{
+		// Key column of a secondary index.
+		{col: 1 /* a */, expected: []string{"primary", "a_idx"}},
+		// Implicit primary key column of every secondary index.
+		{col: 0 /* k */, expected: []string{"primary", "a_idx", "b_idx", "partial_idx", "j_idx"}},
+		// Only stored in b_idx.
+		{col: 3 /* c */, expected: []string{"primary", "b_idx"}},
+		// Only used in the partial index predicate.
+		{col: 4 /* d */, expected: []string{"primary", "partial_idx"}},
+		// Source of the inverted index key.
+		{col: 5 /* j */, expected: []string{"primary", "j_idx"}},
+	}
+	for _, tc := range testCases {
+		t.Run(string(ot.Column(tc.col).ColName()), func(t *testing.T) {
+			indexes, err := ot.IndexesReferencingColumn(tc.col)
+			require.NoError(t, err)
+			var names []string
+			for _, idx := range indexes {
+				names = append(names, string(idx.Name()))
+			}
+			require.Equal(t, tc.expected, names)
+		})
+	}
+
+	// A predicate that can't be parsed results in an error rather than a panic.
+	desc := makeTestTableDesc(t, `
+		CREATE TABLE t (k INT PRIMARY KEY, a INT, b INT, INDEX (a) WHERE b > 0)
+	`)
+	desc.Indexes[0].Predicate = "b >"
+	_, err := makeTestOptTable(t, desc).IndexesReferencingColumn(2 /* b */)
+	require.Error(t, err)
+}
+
+func TestOptCatalogDefaultGCTTL(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	srv, cleanup := startOptCatalogTestServer(t,
+		`ALTER RANGE default CONFIGURE ZONE USING gc.ttlseconds = 600`,
+	)
+	defer cleanup()
+
+	// The zone change has to be gossiped before it is visible in the
+	// SystemConfig, which is read again when the catalog is reset.
+	testutils.SucceedsSoon(t, func() error {
+		srv.oc.reset()
+		ttl, err := srv.oc.DefaultGCTTL()
+		if err != nil {
+			return err
+		}
+		if ttl != 10*time.Minute {
+			return errors.Errorf("expected default GC TTL of 10m, got %s", ttl)
+		}
+		return nil
+	})
+
+	// Without a SystemConfig, the static default zone config is used.
+	srv.oc.cfg = nil
+	ttl, err := srv.oc.DefaultGCTTL()
+	require.NoError(t, err)
+	execCfg := srv.s.ExecutorConfig().(ExecutorConfig)
+	require.Equal(t, time.Duration(execCfg.DefaultZoneConfig.GC.TTLSeconds)*time.Second, ttl)
+}
@@ -467,6 +467,7 @@ https://www.postgresql.org/docs/9.6/catalog-pg-cast.html`,
 		// maintainability anyway.
 		return nil
 	},
+	unimplemented: true,
 }
 
 var pgCatalogAuthIDTable = virtualSchemaTable{
@@ -2107,6 +2108,7 @@ https://www.postgresql.org/docs/9.6/catalog-pg-shdepend.html`,
 	populate: func(ctx context.Context, p *planner, dbContext *dbdesc.Immutable, addRow func(...tree.Datum) error) error {
 		return nil
 	},
+	unimplemented: true,
 }
 
 var pgCatalogTablesTable = virtualSchemaTable{
@@ -85,16 +85,37 @@ func (p *planner) IncrementSequence(ctx context.Context, seqName *tree.TableName
 	if err := p.CheckPrivilege(ctx, descriptor, privilege.UPDATE); err != nil {
 		return 0, err
 	}
+	return p.incrementSequenceBy(ctx, descriptor, 1 /* steps */)
+}
 
+// incrementSequenceBy advances the given sequence as if nextval had been called
+// the given number of times, and returns the last value that was produced. The
+// caller must have checked that the transaction is not read-only and that the
+// user has the UPDATE privilege on the sequence. As with nextval, the sequence
+// is advanced even if the result exceeds its bounds, in which case an error is
+// returned. Virtual sequences have no stored value, so they can only be advanced
+// by one step at a time.
+func (p *planner) incrementSequenceBy(
+	ctx context.Context, descriptor *tabledesc.Immutable, steps int64,
+) (int64, error) {
 	seqOpts := descriptor.SequenceOpts
 	var val int64
 	if seqOpts.Virtual {
+		if steps != 1 {
+			return 0, pgerror.Newf(pgcode.FeatureNotSupported,
+				"cannot reserve values of virtual sequence %q",
+				tree.ErrString((*tree.Name)(&descriptor.Name)))
+		}
 		rowid := builtins.GenerateUniqueInt(p.EvalContext().NodeID.SQLInstanceID())
 		val = int64(rowid)
 	} else {
+		increment := seqOpts.Increment * steps
+		if increment/steps != seqOpts.Increment {
+			return 0, boundsExceededError(descriptor)
+		}
 		seqValueKey := p.ExecCfg().Codec.SequenceKey(uint32(descriptor.ID))
-		val, err = kv.IncrementValRetryable(
-			ctx, p.txn.DB(), seqValueKey, seqOpts.Increment)
+		var err error
+		val, err = kv.IncrementValRetryable(ctx, p.txn.DB(), seqValueKey, increment)
 		if err != nil {
 			if errors.HasType(err, (*roachpb.IntegerOverflowError)(nil)) {
 				return 0, boundsExceededError(descriptor)
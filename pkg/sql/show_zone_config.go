@@ -11,9 +11,7 @@
 package sql
 
 import (
-	"bytes"
 	"context"
-	"strings"
 
 	"github.com/cockroachdb/cockroach/pkg/config"
 	"github.com/cockroachdb/cockroach/pkg/config/zonepb"
@@ -169,18 +167,14 @@ func getShowZoneConfigRow(
 
 // zoneConfigToSQL pretty prints a zone configuration as a SQL string.
 func zoneConfigToSQL(zs *tree.ZoneSpecifier, zone *zonepb.ZoneConfig) (string, error) {
-	constraints, err := yamlMarshalFlow(zonepb.ConstraintsList{
-		Constraints: zone.Constraints,
-		Inherited:   zone.InheritedConstraints})
+	constraints, err := zone.ConstraintsString()
 	if err != nil {
 		return "", err
 	}
-	constraints = strings.TrimSpace(constraints)
-	prefs, err := yamlMarshalFlow(zone.LeasePreferences)
+	prefs, err := zone.LeasePreferencesString()
 	if err != nil {
 		return "", err
 	}
-	prefs = strings.TrimSpace(prefs)
 
 	useComma := false
 	f := tree.NewFmtCtx(tree.FmtParsable)
@@ -323,19 +317,6 @@ func writeComma(f *tree.FmtCtx, useComma bool) {
 	}
 }
 
-func yamlMarshalFlow(v interface{}) (string, error) {
-	var buf bytes.Buffer
-	e := yaml.NewEncoder(&buf)
-	e.UseStyle(yaml.FlowStyle)
-	if err := e.Encode(v); err != nil {
-		return "", err
-	}
-	if err := e.Close(); err != nil {
-		return "", err
-	}
-	return buf.String(), nil
-}
-
 // ascendZoneSpecifier logically ascends the zone hierarchy for the zone
 // specified by (zs, resolvedID) until the zone matching actualID is found, and
 // returns that zone's specifier. Results are undefined if actualID is not in
@@ -95,6 +95,15 @@ var AutomaticStatisticsMinStaleRows = func() *settings.IntSetting {
 	return s
 }()
 
+// StaleRowsThreshold returns the target number of rows that must be updated in
+// a table with the given row count before its statistics are refreshed. It is
+// derived from the AutomaticStatisticsFractionStaleRows and
+// AutomaticStatisticsMinStaleRows cluster settings.
+func StaleRowsThreshold(sv *settings.Values, rowCount float64) int64 {
+	return int64(rowCount*AutomaticStatisticsFractionStaleRows.Get(sv)) +
+		AutomaticStatisticsMinStaleRows.Get(sv)
+}
+
 // DefaultRefreshInterval is the frequency at which the Refresher will check if
 // the stats for each table should be refreshed. It is mutable for testing.
 // NB: Updates to this value after Refresher.Start has been called will not
@@ -431,8 +440,7 @@ func (r *Refresher) maybeRefreshStats(
 		mustRefresh = true
 	}
 
-	targetRows := int64(rowCount*AutomaticStatisticsFractionStaleRows.Get(&r.st.SV)) +
-		AutomaticStatisticsMinStaleRows.Get(&r.st.SV)
+	targetRows := StaleRowsThreshold(&r.st.SV, rowCount)
 	if !mustRefresh && rowsAffected < math.MaxInt32 && r.randGen.randInt(targetRows) >= rowsAffected {
 		// No refresh is happening this time.
 		return
@@ -109,6 +109,26 @@ type virtualSchemaTable struct {
 	// virtualTableNode. This function returns a virtualTableGenerator function
 	// which generates the next row of the virtual table when called.
 	generator func(ctx context.Context, p *planner, db *dbdesc.Immutable) (virtualTableGenerator, cleanupFunc, error)
+
+	// unimplemented, if true, indicates that the table is a stub which never
+	// produces any rows because populating it has not been implemented yet,
+	// even though CockroachDB has the data it would contain (e.g. pg_cast).
+	// Tables which are empty because the corresponding feature does not exist
+	// in CockroachDB (e.g. pg_event_trigger or pg_conversion) are not stubs
+	// and must not set this.
+	unimplemented bool
+}
+
+// isUnimplementedVirtualTable returns true if the given virtual table
+// descriptor corresponds to a virtualSchemaTable which is marked as
+// unimplemented.
+func isUnimplementedVirtualTable(desc catalog.TableDescriptor) bool {
+	schema, ok := virtualSchemas[desc.GetParentSchemaID()]
+	if !ok {
+		return false
+	}
+	def, ok := schema.tableDefs[desc.GetID()].(virtualSchemaTable)
+	return ok && def.unimplemented
 }
 
 // virtualSchemaView represents a view within a virtualSchema